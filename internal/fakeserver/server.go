@@ -14,6 +14,7 @@ import (
 	"net/http"
 	"os"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -23,6 +24,9 @@ type Fakeserver struct {
 	objects map[string]map[string]interface{}
 	debug   bool
 	running bool
+
+	requestsMu sync.Mutex
+	requests   map[string]int
 }
 
 /*NewFakeServer creates a HTTP server used for tests and debugging*/
@@ -30,9 +34,10 @@ func NewFakeServer(iPort int, iObjects map[string]map[string]interface{}, iStart
 	serverMux := http.NewServeMux()
 
 	svr := &Fakeserver{
-		debug:   iDebug,
-		objects: iObjects,
-		running: false,
+		debug:    iDebug,
+		objects:  iObjects,
+		running:  false,
+		requests: make(map[string]int),
 	}
 
 	//If we were passed an argument for where to serve /static from...
@@ -92,11 +97,22 @@ func (svr *Fakeserver) GetServer() *http.Server {
 	return svr.server
 }
 
+/*RequestCount returns how many requests with the method were received for the path*/
+func (svr *Fakeserver) RequestCount(method string, path string) int {
+	svr.requestsMu.Lock()
+	defer svr.requestsMu.Unlock()
+	return svr.requests[method+" "+path]
+}
+
 func (svr *Fakeserver) handleAPIObject(w http.ResponseWriter, r *http.Request) {
 	var obj map[string]interface{}
 	var id string
 	var ok bool
 
+	svr.requestsMu.Lock()
+	svr.requests[r.Method+" "+r.URL.EscapedPath()]++
+	svr.requestsMu.Unlock()
+
 	/* Assume this will never fail */
 	b, _ := ioutil.ReadAll(r.Body)
 
@@ -144,12 +160,30 @@ func (svr *Fakeserver) handleAPIObject(w http.ResponseWriter, r *http.Request) {
 			log.Printf("fakeserver.go: App request")
 		}
 		id = "generatedTestID"
-		obj = svr.objects[id]
+		obj, ok = svr.objects[id]
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
 	} else if path == "/api/exception_lists" && (r.Method == "POST" || r.Method == "DELETE") {
 		if svr.debug {
 			log.Printf("fakeserver.go: App request")
 		}
 		id = "generatedTestID"
+	} else if path == "/api/detection_engine/rules/_bulk_action" && r.Method == "POST" {
+		/* Enable or disable the stored rule without replacing it */
+		var action struct {
+			Action string `json:"action"`
+		}
+		json.Unmarshal(b, &action)
+		rule, ok := svr.objects["rules"]
+		if !ok {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+		rule["enabled"] = action.Action == "enable"
+		w.Write([]byte(`{"success":true,"rules_count":1}`))
+		return
 	} else if path == "/api/object_list" && r.Method == "GET" {
 		/* Provide a URL similar to /api/objects that will also show the number of results
 		   as if a search was performed (which just returns all objects */
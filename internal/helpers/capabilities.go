@@ -0,0 +1,159 @@
+package helpers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Feature is a capability of the detection engine which depends on the stack version and license
+type Feature struct {
+	Name       string
+	MinVersion string
+	License    string
+}
+
+var (
+	FeatureNewTermsRules    = Feature{Name: "new terms rules", MinVersion: "8.4.0", License: "basic"}
+	FeatureAlertSuppression = Feature{Name: "alert suppression", MinVersion: "8.8.0", License: "platinum"}
+	FeatureResponseActions  = Feature{Name: "response actions", MinVersion: "8.8.0", License: "enterprise"}
+	FeatureEsqlRules        = Feature{Name: "ES|QL rules", MinVersion: "8.13.0", License: "basic"}
+)
+
+// licenseLevels orders the license tiers, a trial includes all features
+var licenseLevels = map[string]int{
+	"basic":      0,
+	"standard":   1,
+	"gold":       2,
+	"platinum":   3,
+	"enterprise": 4,
+	"trial":      4,
+}
+
+// ServerInfo describes the Kibana the client is connected to
+type ServerInfo struct {
+	// Version is nil if it could not be detected
+	Version *version.Version
+	// License is the license tier, empty if it could not be detected
+	License string
+}
+
+// serverDetection holds the server info of a client, detected on the first capability check so that
+// commands using no gated feature do not query Kibana. It is shared by all copies of a client.
+type serverDetection struct {
+	// detecting serializes the detection, so concurrent checks query Kibana once
+	detecting sync.Mutex
+	mu        sync.Mutex
+	detected  bool
+	info      ServerInfo
+}
+
+type statusResponse struct {
+	Version struct {
+		Number string `json:"number"`
+	} `json:"version"`
+}
+
+type licensingResponse struct {
+	License struct {
+		Type   string `json:"type"`
+		Status string `json:"status"`
+	} `json:"license"`
+}
+
+// DetectServerInfo queries the Kibana status and licensing endpoints and stores the
+// version and license tier on the client. Nothing is stored unless both are detected.
+func (c *Client) DetectServerInfo(ctx context.Context) error {
+	var status statusResponse
+	if err := c.Get(ctx, "/status", &status); err != nil {
+		return fmt.Errorf("unable to detect the Kibana version: %w", err)
+	}
+	v, err := version.NewVersion(status.Version.Number)
+	if err != nil {
+		return fmt.Errorf("unable to parse the Kibana version %q: %w", status.Version.Number, err)
+	}
+
+	var licensing licensingResponse
+	if err := c.Get(ctx, "/licensing/info", &licensing); err != nil {
+		return fmt.Errorf("unable to detect the license: %w", err)
+	}
+	license := "basic"
+	if licensing.License.Status == "active" {
+		license = licensing.License.Type
+	}
+
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	c.server.info = ServerInfo{Version: v, License: license}
+	c.server.detected = true
+	return nil
+}
+
+// EnsureServerInfo detects the version and license of Kibana unless they were detected already.
+// A failed detection is not cached, the next capability check tries again.
+func (c *Client) EnsureServerInfo(ctx context.Context) error {
+	c.server.detecting.Lock()
+	defer c.server.detecting.Unlock()
+	c.server.mu.Lock()
+	detected := c.server.detected
+	c.server.mu.Unlock()
+	if detected {
+		return nil
+	}
+
+	if err := c.DetectServerInfo(ctx); err != nil {
+		return err
+	}
+	info := c.ServerInfo()
+	tflog.Info(ctx, "Detected Kibana", map[string]interface{}{
+		"version": info.Version.String(),
+		"license": info.License,
+	})
+	return nil
+}
+
+// ServerInfo returns the detected version and license of Kibana
+func (c *Client) ServerInfo() ServerInfo {
+	c.server.mu.Lock()
+	defer c.server.mu.Unlock()
+	return c.server.info
+}
+
+// CheckVersion returns an error if the detected Kibana version does not support the feature,
+// a version which cannot be detected is assumed to support every feature. Only the major.minor.patch core of the
+// version is compared, so prerelease builds like 8.14.0-SNAPSHOT support the features of their release.
+func (c *Client) CheckVersion(ctx context.Context, feature Feature) error {
+	if err := c.EnsureServerInfo(ctx); err != nil {
+		return nil
+	}
+	info := c.ServerInfo()
+	if info.Version == nil {
+		return nil
+	}
+	if info.Version.Core().LessThan(version.Must(version.NewVersion(feature.MinVersion))) {
+		return fmt.Errorf("%s require Kibana %s or later, the target stack runs %s",
+			feature.Name, feature.MinVersion, info.Version)
+	}
+	return nil
+}
+
+// CheckLicense returns an error if the detected license does not include the feature,
+// a license which cannot be detected is assumed to include every feature
+func (c *Client) CheckLicense(ctx context.Context, feature Feature) error {
+	if err := c.EnsureServerInfo(ctx); err != nil {
+		return nil
+	}
+	info := c.ServerInfo()
+	level, ok := licenseLevels[info.License]
+	if !ok {
+		return nil
+	}
+	if level < licenseLevels[feature.License] {
+		return fmt.Errorf("%s require a %s license, the target stack has a %s license",
+			feature.Name, feature.License, info.License)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+func newServerInfoTestClient(t *testing.T, versionNumber string, statusRequests *int) (*Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/status":
+			*statusRequests++
+			w.Write([]byte(`{"name":"kibana","version":{"number":"` + versionNumber + `","build_snapshot":false}}`))
+		case "/api/licensing/info":
+			w.Write([]byte(`{"license":{"type":"platinum","status":"active"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	client, err := NewClient(&NewClientInput{Hostname: serverURL.Hostname(), Port: port})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, server.Close
+}
+
+func TestDetectServerInfo(t *testing.T) {
+	var statusRequests int
+	client, closeServer := newServerInfoTestClient(t, "8.12.2", &statusRequests)
+	defer closeServer()
+
+	if statusRequests != 0 {
+		t.Errorf("expected no detection before a capability is checked, got %d requests", statusRequests)
+	}
+
+	ctx := context.Background()
+	if err := client.CheckVersion(ctx, FeatureEsqlRules); err == nil {
+		t.Error("expected ES|QL rules to be unsupported on 8.12")
+	}
+	if client.ServerInfo().Version.String() != "8.12.2" || client.ServerInfo().License != "platinum" {
+		t.Errorf("unexpected server info: %+v", client.ServerInfo())
+	}
+	if err := client.WithSpace("team-a").CheckVersion(ctx, FeatureAlertSuppression); err != nil {
+		t.Errorf("expected alert suppression to be supported on 8.12, got error: %s", err)
+	}
+	if err := client.CheckLicense(ctx, FeatureAlertSuppression); err != nil {
+		t.Errorf("expected alert suppression to be licensed, got error: %s", err)
+	}
+	if err := client.CheckLicense(ctx, FeatureResponseActions); err == nil {
+		t.Error("expected response actions to require an enterprise license")
+	}
+	if statusRequests != 1 {
+		t.Errorf("expected the server info to be detected once for all copies of the client, got %d requests", statusRequests)
+	}
+}
+
+func TestCheckVersionPrerelease(t *testing.T) {
+	var statusRequests int
+	client, closeServer := newServerInfoTestClient(t, "8.13.0-SNAPSHOT", &statusRequests)
+	defer closeServer()
+
+	if err := client.CheckVersion(context.Background(), FeatureEsqlRules); err != nil {
+		t.Errorf("expected a prerelease build to support the features of its release, got error: %s", err)
+	}
+}
+
+func TestEnsureServerInfoRetries(t *testing.T) {
+	var licensingRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/status":
+			w.Write([]byte(`{"version":{"number":"8.12.2"}}`))
+		case "/api/licensing/info":
+			licensingRequests++
+			if licensingRequests == 1 {
+				http.Error(w, `{"statusCode":500,"message":"Internal Server Error"}`, http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`{"license":{"type":"gold","status":"active"}}`))
+		}
+	}))
+	defer server.Close()
+	client, err := NewClient(&NewClientInput{Endpoints: []string{server.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.EnsureServerInfo(context.Background()); err == nil {
+		t.Fatal("expected the failed license detection to be returned")
+	}
+	if info := client.ServerInfo(); info.Version != nil || info.License != "" {
+		t.Errorf("expected nothing to be stored after a failed detection, got %+v", info)
+	}
+	if err := client.CheckLicense(context.Background(), FeatureAlertSuppression); err == nil {
+		t.Error("expected the detection to be retried and alert suppression to require a platinum license")
+	}
+	if err := client.EnsureServerInfo(context.Background()); err != nil || licensingRequests != 2 {
+		t.Errorf("expected the successful detection to be cached, got %d requests and error: %v", licensingRequests, err)
+	}
+}
+
+func TestCheckVersionUndetected(t *testing.T) {
+	client, err := NewClient(&NewClientInput{Endpoints: []string{"http://127.0.0.1:1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CheckVersion(context.Background(), FeatureEsqlRules); err != nil {
+		t.Errorf("expected an unknown version to support every feature, got error: %s", err)
+	}
+	if err := client.CheckLicense(context.Background(), FeatureResponseActions); err != nil {
+		t.Errorf("expected an unknown license to include every feature, got error: %s", err)
+	}
+}
@@ -2,19 +2,30 @@ package helpers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"time"
+
+	"golang.org/x/net/http/httpproxy"
 )
 
 // Client provides a connection to the Confluence API
 type Client struct {
-	client    *http.Client
-	baseURL   *url.URL
-	basePath  string
-	publicURL *url.URL
+	client        *http.Client
+	endpoints     *endpointPool
+	basePath      string
+	publicURL     *url.URL
+	authorization string
+	maxRetries    int
+	retryWaitMax  time.Duration
+	server        *serverDetection
+	headers       map[string]string
+	throttle      *throttle
 }
 
 // NewClientInput provides information to connect to the Confluence API
@@ -24,43 +35,138 @@ type NewClientInput struct {
 	UseTls   bool
 	Username string
 	Password string
-}
-
-// ErrorResponse describes why a request failed
-type ErrorResponse struct {
-	StatusCode int    `json:"status_code,omitempty"`
-	Message    string `json:"message,omitempty"`
+	// Endpoints are the URLs of several Kibana nodes, used instead of Hostname, Port and UseTls.
+	// Requests fail over to the next node when a node cannot be reached or answers with a 5xx status.
+	Endpoints []string
+	// EndpointCooldown is how long a failed node is skipped, defaults to one minute
+	EndpointCooldown time.Duration
+	// ApiKey is sent as `Authorization: ApiKey <key>` (base64 encoded id:api_key)
+	ApiKey string
+	// BearerToken is sent as `Authorization: Bearer <token>`
+	BearerToken string
+	// ServiceToken is an Elastic service account token, also sent as a bearer token
+	ServiceToken string
+	// SpaceID is the Kibana space used when a resource does not set its own
+	SpaceID string
+	// CACertificate is a PEM encoded CA certificate or the path to one, added to the system roots
+	CACertificate string
+	// ClientCertificate and ClientKey are PEM encoded or paths to PEM files, used for mutual TLS
+	ClientCertificate string
+	ClientKey         string
+	// InsecureSkipVerify disables the verification of the Kibana server certificate
+	InsecureSkipVerify bool
+	// MaxRetries is the number of times a request failing with a transient error is retried
+	MaxRetries int
+	// RetryWaitMax caps the wait between two attempts
+	RetryWaitMax time.Duration
+	// Timeout of a single request, defaults to 10 seconds
+	Timeout time.Duration
+	// ProxyURL is the proxy used for all requests, by default HTTPS_PROXY and NO_PROXY are honoured
+	ProxyURL string
+	// Headers are sent with every request
+	Headers map[string]string
+	// MaxRequestsPerSecond limits the request rate, zero disables the limit
+	MaxRequestsPerSecond float64
+	// MaxConcurrentRequests limits the number of requests in flight, zero disables the limit
+	MaxConcurrentRequests int
 }
 
 // NewClient returns an authenticated client ready to use
-func NewClient(input *NewClientInput) *Client {
-	publicURL := url.URL{
-		Scheme: ifThenElse(input.UseTls, "https", "http").(string),
-		Host:   fmt.Sprintf(`%s:%d`, input.Hostname, input.Port),
+func NewClient(input *NewClientInput) (*Client, error) {
+	endpoints, err := parseEndpoints(input)
+	if err != nil {
+		return nil, err
 	}
 
-	// Default
-	basePath := "/api"
+	publicURL := *endpoints[0]
+	publicURL.User = nil
+
+	basePath := spaceBasePath(input.SpaceID)
 
-	baseURL := url.URL{
-		Scheme: ifThenElse(input.UseTls, "https", "http").(string),
-		Host:   fmt.Sprintf(`%s:%d`, input.Hostname, input.Port),
+	tlsConfig, err := newTLSConfig(input)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if input.ProxyURL != "" {
+		proxy, err := newProxyFunc(input.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = proxy
 	}
-	baseURL.User = url.UserPassword(input.Username, input.Password)
+
 	return &Client{
 		client: &http.Client{
-			Timeout: time.Second * 10,
+			Timeout:   ifThenElse(input.Timeout > 0, input.Timeout, time.Second*10).(time.Duration),
+			Transport: transport,
 		},
-		baseURL:   &baseURL,
-		basePath:  basePath,
-		publicURL: &publicURL,
+		endpoints:     newEndpointPool(endpoints, input.EndpointCooldown),
+		basePath:      basePath,
+		publicURL:     &publicURL,
+		authorization: authorizationHeader(input),
+		maxRetries:    input.MaxRetries,
+		retryWaitMax:  input.RetryWaitMax,
+		server:        &serverDetection{},
+		headers:       input.Headers,
+		throttle:      newThrottle(input.MaxRequestsPerSecond, input.MaxConcurrentRequests),
+	}, nil
+}
+
+// newProxyFunc returns a proxy function which sends all requests through the given proxy,
+// except for the hosts excluded by the NO_PROXY environment variable
+func newProxyFunc(proxyURL string) (func(*http.Request) (*url.URL, error), error) {
+	if _, err := url.Parse(proxyURL); err != nil {
+		return nil, fmt.Errorf("invalid proxy url: %w", err)
 	}
+	proxyConfig := httpproxy.Config{
+		HTTPProxy:  proxyURL,
+		HTTPSProxy: proxyURL,
+		NoProxy:    firstNonEmpty(os.Getenv("NO_PROXY"), os.Getenv("no_proxy")),
+	}
+	proxyFunc := proxyConfig.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}, nil
+}
+
+// WithSpace returns a client which sends its requests to the given Kibana space,
+// an empty space id keeps the space of the current client
+func (c *Client) WithSpace(spaceID string) *Client {
+	if spaceID == "" {
+		return c
+	}
+	spaceClient := *c
+	spaceClient.basePath = spaceBasePath(spaceID)
+	return &spaceClient
+}
+
+func spaceBasePath(spaceID string) string {
+	if spaceID == "" || spaceID == "default" {
+		return "/api"
+	}
+	return "/s/" + url.PathEscape(spaceID) + "/api"
+}
+
+// authorizationHeader returns the Authorization header value for token based
+// authentication, or an empty string when basic auth (or none) is used
+func authorizationHeader(input *NewClientInput) string {
+	switch {
+	case input.ApiKey != "":
+		return "ApiKey " + input.ApiKey
+	case input.BearerToken != "":
+		return "Bearer " + input.BearerToken
+	case input.ServiceToken != "":
+		return "Bearer " + input.ServiceToken
+	}
+	return ""
 }
 
 // GetString uses the client to send a GET request and returns a string
-func (c *Client) GetString(path string) (string, error) {
+func (c *Client) GetString(ctx context.Context, path string) (string, error) {
 	body := new(bytes.Buffer)
-	responseBody, err := c.doRaw("GET", path, "", body)
+	responseBody, err := c.doRaw(ctx, "GET", path, "", body)
 	if err != nil {
 		return "", err
 	}
@@ -69,19 +175,19 @@ func (c *Client) GetString(path string) (string, error) {
 }
 
 // Get uses the client to send a GET request
-func (c *Client) Get(path string, result interface{}) error {
+func (c *Client) Get(ctx context.Context, path string, result interface{}) error {
 	body := new(bytes.Buffer)
-	return c.do("GET", path, "", body, result)
+	return c.do(ctx, "GET", path, "", body, result)
 }
 
 // Delete uses the client to send a DELETE request
-func (c *Client) Delete(path string) error {
+func (c *Client) Delete(ctx context.Context, path string) error {
 	body := new(bytes.Buffer)
-	return c.do("DELETE", path, "", body, nil)
+	return c.do(ctx, "DELETE", path, "", body, nil)
 }
 
 // Post uses the client to send a POST request
-func (c *Client) Post(path string, body interface{}, result interface{}, itemsToRemove []string) error {
+func (c *Client) Post(ctx context.Context, path string, body interface{}, result interface{}, itemsToRemove []string) error {
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
 		return err
@@ -93,11 +199,11 @@ func (c *Client) Post(path string, body interface{}, result interface{}, itemsTo
 	if err != nil {
 		return err
 	}
-	return c.do("POST", path, "application/json", b, result)
+	return c.do(ctx, "POST", path, "application/json", b, result)
 }
 
 // Put uses the client to send a PUT request
-func (c *Client) Put(path string, body interface{}, result interface{}, itemsToRemove []string) error {
+func (c *Client) Put(ctx context.Context, path string, body interface{}, result interface{}, itemsToRemove []string) error {
 	bodyBytes, err := json.Marshal(body)
 	if err != nil {
 		return err
@@ -109,7 +215,7 @@ func (c *Client) Put(path string, body interface{}, result interface{}, itemsToR
 	if err != nil {
 		return err
 	}
-	return c.do("PUT", path, "application/json", b, result)
+	return c.do(ctx, "PUT", path, "application/json", b, result)
 }
 
 func JsonBytesBuffer(body interface{}) (*bytes.Buffer, error) {
@@ -132,8 +238,8 @@ func bytesBufferJSON(bodyBytes *bytes.Buffer, result interface{}) error {
 	return json.NewDecoder(reader).Decode(&result)
 }
 
-func (c *Client) do(method, path, contentType string, body *bytes.Buffer, result interface{}) error {
-	responseBody, err := c.doRaw(method, path, contentType, body)
+func (c *Client) do(ctx context.Context, method, path, contentType string, body *bytes.Buffer, result interface{}) error {
+	responseBody, err := c.doRaw(ctx, method, path, contentType, body)
 	if err != nil {
 		return err
 	}
@@ -141,55 +247,124 @@ func (c *Client) do(method, path, contentType string, body *bytes.Buffer, result
 }
 
 // do uses the client to send a specified request
-func (c *Client) doRaw(method, path, contentType string, body *bytes.Buffer) (*bytes.Buffer, error) {
+func (c *Client) doRaw(ctx context.Context, method, path, contentType string, body *bytes.Buffer) (*bytes.Buffer, error) {
 	fullPath := c.basePath + path
-	u, err := c.baseURL.Parse(fullPath)
+	requestBody := body.Bytes()
+	for attempt := 0; ; attempt++ {
+		resp, responseBody, err := c.sendWithFailover(ctx, method, fullPath, contentType, requestBody, attempt)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
+			if attempt < c.maxRetries && (isIdempotent(method) || isDialError(err)) {
+				if err := sleepContext(ctx, retryWait(attempt, nil, c.retryWaitMax)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			return nil, err
+		}
+		if attempt < c.maxRetries && shouldRetryStatus(method, resp.StatusCode) {
+			// A Retry-After past the deadline fails the request now with the error of Kibana
+			wait := retryWait(attempt, resp, c.retryWaitMax)
+			if !exceedsDeadline(ctx, wait) {
+				if err := sleepContext(ctx, wait); err != nil {
+					return nil, err
+				}
+				continue
+			}
+		}
+		return readResponse(method, fullPath, resp.StatusCode, responseBody)
+	}
+}
+
+// sendWithFailover sends the request to the first healthy node. When the node cannot be reached or
+// answers with a server error, it is marked unhealthy and the request is sent to the next node, until
+// every node was tried once. A non-idempotent request answered with a server error other than 503 is
+// not sent again, the node may have applied it. Failing over does not depend on the retry budget.
+func (c *Client) sendWithFailover(ctx context.Context, method, fullPath, contentType string, requestBody []byte, attempt int) (*http.Response, []byte, error) {
+	tried := make(map[int]bool)
+	for {
+		endpoint := c.endpoints.next()
+		tried[endpoint] = true
+		resp, responseBody, err := c.send(ctx, endpoint, method, fullPath, contentType, requestBody, attempt)
+		failed := false
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, nil, err
+			}
+			c.endpoints.markUnhealthy(endpoint)
+			failed = isDialError(err)
+		} else if isUnhealthyStatus(resp.StatusCode) {
+			c.endpoints.markUnhealthy(endpoint)
+			failed = shouldFailOverStatus(method, resp.StatusCode)
+		} else {
+			c.endpoints.markHealthy(endpoint)
+		}
+		if !failed || tried[c.endpoints.next()] {
+			return resp, responseBody, err
+		}
+	}
+}
+
+// send sends a single request to the given node and reads the response body
+func (c *Client) send(ctx context.Context, endpoint int, method, fullPath, contentType string, requestBody []byte, attempt int) (*http.Response, []byte, error) {
+	u, err := c.endpoints.url(endpoint, fullPath)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	req, err := http.NewRequest(method, u.String(), body)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(requestBody))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
 	}
 	if contentType != "" {
 		req.Header.Set("Content-Type", contentType)
 	}
 	req.Header.Add("kbn-xsrf", "monitoring")
+	if c.authorization != "" {
+		req.Header.Set("Authorization", c.authorization)
+	}
+	release, err := c.throttle.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+	logRequest(ctx, req, requestBody, attempt, c.headers)
+	start := time.Now()
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, err
+		logRequestError(ctx, req, err, time.Since(start))
+		return nil, nil, err
+	}
+	responseBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, nil, err
 	}
-	defer resp.Body.Close()
+	logResponse(ctx, req, resp, responseBody, time.Since(start))
+	return resp, responseBody, nil
+}
+
+// readResponse returns the body of a response, or turns an unexpected status code into an error
+func readResponse(method, fullPath string, statusCode int, responseBody []byte) (*bytes.Buffer, error) {
 	var expectedStatusCode = map[string][]int{
 		"POST":   {200, 201},
 		"PUT":    {200},
 		"GET":    {200},
 		"DELETE": {200, 204},
 	}
-	if !contains(expectedStatusCode[method], resp.StatusCode) {
-		var responseBody string
-		var errResponse ErrorResponse
-		err = json.NewDecoder(resp.Body).Decode(&errResponse)
-		if err != nil {
-			responseBody = "Could not decode error"
-		} else {
-			responseBody = errResponse.String()
-		}
-		s := body.String()
-		return nil, fmt.Errorf("%s\n\n%s %s\n%s\n\n%s",
-			resp.Status, method, fullPath, s, responseBody)
-	}
-	result := new(bytes.Buffer)
-	_, err = result.ReadFrom(resp.Body)
-	if err != nil {
-		return nil, err
+	if !Contains(expectedStatusCode[method], statusCode) {
+		return nil, newAPIError(method, fullPath, statusCode, responseBody)
 	}
-	return result, nil
+	return bytes.NewBuffer(responseBody), nil
 }
 
 func (e *ErrorResponse) String() string {
 	return fmt.Sprintf("%s\nCode: %d",
-		e.Message, e.StatusCode)
+		firstNonEmpty(e.Message, e.Error), e.StatusCode)
 }
 
 // URL returns the public URL for a given path
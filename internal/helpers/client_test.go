@@ -0,0 +1,316 @@
+package helpers
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClientWithSpace(t *testing.T) {
+	client, err := NewClient(&NewClientInput{Hostname: "localhost", Port: 5601})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.basePath != "/api" {
+		t.Errorf("expected default base path, got %s", client.basePath)
+	}
+	if client.WithSpace("") != client {
+		t.Error("expected an empty space id to keep the client")
+	}
+	if basePath := client.WithSpace("default").basePath; basePath != "/api" {
+		t.Errorf("expected default space to use /api, got %s", basePath)
+	}
+	if basePath := client.WithSpace("team-a").basePath; basePath != "/s/team-a/api" {
+		t.Errorf("expected space base path, got %s", basePath)
+	}
+
+	spaceClient, err := NewClient(&NewClientInput{Hostname: "localhost", Port: 5601, SpaceID: "team-a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spaceClient.basePath != "/s/team-a/api" {
+		t.Errorf("expected provider space base path, got %s", spaceClient.basePath)
+	}
+	if basePath := spaceClient.WithSpace("team-b").basePath; basePath != "/s/team-b/api" {
+		t.Errorf("expected resource space to override provider space, got %s", basePath)
+	}
+}
+
+func TestClientCACertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"username":"elastic"}`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	untrusted, err := NewClient(&NewClientInput{Hostname: serverURL.Hostname(), Port: port, UseTls: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := untrusted.GetString(context.Background(), "/status"); err == nil {
+		t.Error("expected the self signed certificate to be rejected")
+	}
+
+	trusted, err := NewClient(&NewClientInput{Hostname: serverURL.Hostname(), Port: port, UseTls: true, CACertificate: string(caPEM)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := trusted.GetString(context.Background(), "/status"); err != nil {
+		t.Errorf("expected the CA certificate to be trusted, got error: %s", err)
+	}
+
+	insecure, err := NewClient(&NewClientInput{Hostname: serverURL.Hostname(), Port: port, UseTls: true, InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := insecure.GetString(context.Background(), "/status"); err != nil {
+		t.Errorf("expected verification to be skipped, got error: %s", err)
+	}
+
+	if _, err := NewClient(&NewClientInput{Hostname: "localhost", ClientCertificate: string(caPEM)}); err == nil {
+		t.Error("expected an error for a client certificate without a key")
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method == "POST" || requests < 3 {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"status_code":502,"message":"Bad Gateway"}`, http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	client, err := NewClient(&NewClientInput{Hostname: serverURL.Hostname(), Port: port, MaxRetries: 3, RetryWaitMax: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetString(context.Background(), "/status"); err != nil {
+		t.Errorf("expected the GET request to succeed after retries, got error: %s", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 attempts, got %d", requests)
+	}
+
+	requests = 0
+	if err := client.Post(context.Background(), "/status", map[string]string{}, nil, nil); err == nil {
+		t.Error("expected the POST request to fail")
+	}
+	if requests != 1 {
+		t.Errorf("expected a POST failing with 502 not to be retried, got %d attempts", requests)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if wait, ok := parseRetryAfter("7"); !ok || wait != 7*time.Second {
+		t.Errorf("expected 7s, got %s", wait)
+	}
+	if wait, ok := parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)); !ok || wait < 59*time.Minute {
+		t.Errorf("expected about an hour, got %s", wait)
+	}
+	if _, ok := parseRetryAfter("soon"); ok {
+		t.Error("expected an invalid Retry-After to be ignored")
+	}
+	if wait := retryWait(10, nil, 2*time.Second); wait > 2*time.Second || wait < time.Second {
+		t.Errorf("expected the backoff to be capped, got %s", wait)
+	}
+	resp := &http.Response{Header: http.Header{"Retry-After": {"7"}}}
+	if wait := retryWait(0, resp, 2*time.Second); wait != 7*time.Second {
+		t.Errorf("expected Retry-After to be honoured beyond the maximum wait, got %s", wait)
+	}
+}
+
+func TestClientContextCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		http.Error(w, `{"status_code":503,"message":"Service Unavailable"}`, http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	serverURL, _ := url.Parse(server.URL)
+	port, _ := strconv.Atoi(serverURL.Port())
+	client, err := NewClient(&NewClientInput{Hostname: serverURL.Hostname(), Port: port, MaxRetries: 3, RetryWaitMax: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = client.GetString(ctx, "/status")
+	if err == nil {
+		t.Error("expected the request to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the cancelled context to stop waiting for a retry, took %s", elapsed)
+	}
+	if !hasStatusCode(err, http.StatusServiceUnavailable) {
+		t.Errorf("expected a Retry-After past the deadline to return the error of Kibana, got: %v", err)
+	}
+}
+
+func TestClientProxyAndHeaders(t *testing.T) {
+	var proxiedHost, tenant string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+		tenant = r.Header.Get("X-Tenant")
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient(&NewClientInput{
+		Hostname: "kibana.example",
+		Port:     5601,
+		ProxyURL: proxy.URL,
+		Headers:  map[string]string{"X-Tenant": "team-a"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetString(context.Background(), "/status"); err != nil {
+		t.Fatal(err)
+	}
+	if proxiedHost != "kibana.example:5601" {
+		t.Errorf("expected the request to be sent through the proxy, got host %q", proxiedHost)
+	}
+	if tenant != "team-a" {
+		t.Errorf("expected the extra header to be sent, got %q", tenant)
+	}
+}
+
+func TestClientFailover(t *testing.T) {
+	var healthyRequests, failingRequests int
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyRequests++
+		w.Write([]byte(`{}`))
+	}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingRequests++
+		http.Error(w, `{"status_code":503,"message":"Kibana server is not ready yet"}`, http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	stopped := httptest.NewServer(http.NotFoundHandler())
+	stopped.Close()
+
+	client, err := NewClient(&NewClientInput{
+		Endpoints:    []string{stopped.URL, failing.URL, healthy.URL},
+		MaxRetries:   3,
+		RetryWaitMax: time.Minute,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := client.Post(context.Background(), "/detection_engine/rules", map[string]string{}, nil, nil); err != nil {
+		t.Fatalf("expected the request to fail over to the healthy node, got error: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the failover not to wait for a backoff, took %s", elapsed)
+	}
+	if failingRequests != 1 || healthyRequests != 1 {
+		t.Errorf("expected one request to each started node, got %d failing and %d healthy", failingRequests, healthyRequests)
+	}
+
+	// The failed nodes are skipped during their cooldown
+	if _, err := client.GetString(context.Background(), "/status"); err != nil {
+		t.Fatal(err)
+	}
+	if failingRequests != 1 || healthyRequests != 2 {
+		t.Errorf("expected unhealthy nodes to be skipped, got %d failing and %d healthy", failingRequests, healthyRequests)
+	}
+
+	if _, err := NewClient(&NewClientInput{Endpoints: []string{"kibana.example:5601"}}); err == nil {
+		t.Error("expected an endpoint without a scheme to be rejected")
+	}
+}
+
+func TestClientFailoverWithoutRetries(t *testing.T) {
+	var requests int
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{}`))
+	}))
+	defer healthy.Close()
+	stopped := httptest.NewServer(http.NotFoundHandler())
+	stopped.Close()
+
+	client, err := NewClient(&NewClientInput{
+		Endpoints:  []string{stopped.URL, healthy.URL},
+		MaxRetries: 0,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Post(context.Background(), "/detection_engine/rules", map[string]string{}, nil, nil); err != nil {
+		t.Fatalf("expected the request to fail over without a retry budget, got error: %s", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected one request to the healthy node, got %d", requests)
+	}
+}
+
+func TestClientFailoverOnServerError(t *testing.T) {
+	var failingRequests, healthyRequests int
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingRequests++
+		http.Error(w, `{"status_code":500,"message":"Internal Server Error"}`, http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyRequests++
+		w.Write([]byte(`{}`))
+	}))
+	defer healthy.Close()
+
+	client, err := NewClient(&NewClientInput{Endpoints: []string{failing.URL, healthy.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetString(context.Background(), "/status"); err != nil {
+		t.Fatalf("expected the request to fail over on a 500, got error: %s", err)
+	}
+	if failingRequests != 1 || healthyRequests != 1 {
+		t.Errorf("expected one request to each node, got %d failing and %d healthy", failingRequests, healthyRequests)
+	}
+
+	// A POST may have been applied by the failing node, so it is not sent to another node
+	post, err := NewClient(&NewClientInput{Endpoints: []string{failing.URL, healthy.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := post.Post(context.Background(), "/detection_engine/rules", map[string]string{}, nil, nil); !hasStatusCode(err, http.StatusInternalServerError) {
+		t.Fatalf("expected the server error of the POST to be returned, got: %v", err)
+	}
+	if failingRequests != 2 || healthyRequests != 1 {
+		t.Errorf("expected the POST to be sent to the failing node only, got %d failing and %d healthy", failingRequests-1, healthyRequests-1)
+	}
+
+	// A single node answering with a server error returns the error without looping
+	single, err := NewClient(&NewClientInput{Endpoints: []string{failing.URL}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := single.GetString(context.Background(), "/status"); err == nil {
+		t.Error("expected the server error to be returned")
+	}
+	if failingRequests != 3 {
+		t.Errorf("expected a single request to the only node, got %d", failingRequests-2)
+	}
+}
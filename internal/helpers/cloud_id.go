@@ -0,0 +1,70 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CloudID describes the Kibana endpoint encoded in an Elastic Cloud ID
+type CloudID struct {
+	Hostname string
+	Port     int
+	UseTls   bool
+}
+
+// DecodeCloudID decodes an Elastic Cloud ID (`<label>:<base64 payload>`) into the Kibana endpoint.
+// The payload has the form `<domain>[:<port>]$<elasticsearch uuid>$<kibana uuid>[:<port>]`.
+func DecodeCloudID(cloudID string) (*CloudID, error) {
+	encoded := cloudID
+	if i := strings.LastIndex(cloudID, ":"); i >= 0 {
+		encoded = cloudID[i+1:]
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("cloud id is not valid base64: %w", err)
+		}
+	}
+
+	parts := strings.Split(string(decoded), "$")
+	if len(parts) < 3 || parts[0] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("cloud id does not contain a Kibana endpoint")
+	}
+
+	port := 443
+	domain, domainPort, err := splitHostPort(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	if domainPort != 0 {
+		port = domainPort
+	}
+	kibanaID, kibanaPort, err := splitHostPort(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if kibanaPort != 0 {
+		port = kibanaPort
+	}
+
+	return &CloudID{
+		Hostname: kibanaID + "." + domain,
+		Port:     port,
+		UseTls:   true,
+	}, nil
+}
+
+func splitHostPort(value string) (string, int, error) {
+	host, portString, found := strings.Cut(value, ":")
+	if !found {
+		return host, 0, nil
+	}
+	port, err := strconv.Atoi(portString)
+	if err != nil {
+		return "", 0, fmt.Errorf("cloud id contains an invalid port %q", portString)
+	}
+	return host, port, nil
+}
@@ -0,0 +1,36 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestDecodeCloudID(t *testing.T) {
+	cases := []struct {
+		name     string
+		payload  string
+		hostname string
+		port     int
+	}{
+		{name: "default port", payload: "us-east-1.aws.found.io$es123$kb456", hostname: "kb456.us-east-1.aws.found.io", port: 443},
+		{name: "domain port", payload: "us-east-1.aws.found.io:9243$es123$kb456", hostname: "kb456.us-east-1.aws.found.io", port: 9243},
+		{name: "kibana port", payload: "us-east-1.aws.found.io:443$es123$kb456:9243", hostname: "kb456.us-east-1.aws.found.io", port: 9243},
+	}
+	for _, c := range cases {
+		cloudID := "my-deployment:" + base64.StdEncoding.EncodeToString([]byte(c.payload))
+		result, err := DecodeCloudID(cloudID)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", c.name, err)
+		}
+		if result.Hostname != c.hostname || result.Port != c.port || !result.UseTls {
+			t.Errorf("%s: got %+v", c.name, result)
+		}
+	}
+
+	if _, err := DecodeCloudID("my-deployment:" + base64.StdEncoding.EncodeToString([]byte("found.io$es123"))); err == nil {
+		t.Error("expected an error for a cloud id without a Kibana endpoint")
+	}
+	if _, err := DecodeCloudID("my-deployment:not base64!"); err == nil {
+		t.Error("expected an error for a cloud id that is not base64")
+	}
+}
@@ -0,0 +1,114 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultEndpointCooldown is how long a failed Kibana node is skipped when no cooldown is configured
+const defaultEndpointCooldown = time.Minute
+
+// endpointPool holds the Kibana nodes of a client. Requests go to the first healthy node, a node
+// failing with a connection error or a server error is skipped until its cooldown expired.
+// The pool is shared by all copies of a client, so every resource sees the same node health.
+type endpointPool struct {
+	endpoints []*url.URL
+	cooldown  time.Duration
+
+	mu             sync.Mutex
+	unhealthyUntil []time.Time
+}
+
+func newEndpointPool(endpoints []*url.URL, cooldown time.Duration) *endpointPool {
+	return &endpointPool{
+		endpoints:      endpoints,
+		cooldown:       ifThenElse(cooldown > 0, cooldown, defaultEndpointCooldown).(time.Duration),
+		unhealthyUntil: make([]time.Time, len(endpoints)),
+	}
+}
+
+// next returns the index of the node the next request is sent to. When every node is
+// unhealthy, the one whose cooldown expires first is tried rather than failing outright.
+func (p *endpointPool) next() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	best := 0
+	for i, until := range p.unhealthyUntil {
+		if !now.Before(until) {
+			return i
+		}
+		if until.Before(p.unhealthyUntil[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// markUnhealthy skips the node until its cooldown expired
+func (p *endpointPool) markUnhealthy(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unhealthyUntil[i] = time.Now().Add(p.cooldown)
+}
+
+// markHealthy makes the node available again after it answered a request
+func (p *endpointPool) markHealthy(i int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unhealthyUntil[i] = time.Time{}
+}
+
+// url returns the URL of the given path on a node, keeping a path prefix of the node URL
+func (p *endpointPool) url(i int, path string) (*url.URL, error) {
+	endpoint := p.endpoints[i]
+	return endpoint.Parse(strings.TrimSuffix(endpoint.Path, "/") + path)
+}
+
+// isUnhealthyStatus reports whether a response status is a server error, so the node is marked unhealthy
+func isUnhealthyStatus(statusCode int) bool {
+	return statusCode >= http.StatusInternalServerError
+}
+
+// shouldFailOverStatus reports whether a request answered with a server error is sent to another node.
+// The node may have applied a non-idempotent request already, unless it was rejected with a 503.
+func shouldFailOverStatus(method string, statusCode int) bool {
+	return isUnhealthyStatus(statusCode) && (isIdempotent(method) || statusCode == http.StatusServiceUnavailable)
+}
+
+// isDialError reports whether the connection could not be established, so the request was never
+// sent and can be repeated on another node whatever its method
+func isDialError(err error) bool {
+	var opError *net.OpError
+	return errors.As(err, &opError) && opError.Op == "dial"
+}
+
+// parseEndpoints parses the URLs of the Kibana nodes and adds the basic auth credentials to them
+func parseEndpoints(input *NewClientInput) ([]*url.URL, error) {
+	rawEndpoints := input.Endpoints
+	if len(rawEndpoints) == 0 {
+		rawEndpoints = []string{fmt.Sprintf("%s://%s:%d",
+			ifThenElse(input.UseTls, "https", "http").(string), input.Hostname, input.Port)}
+	}
+	endpoints := make([]*url.URL, 0, len(rawEndpoints))
+	for _, rawEndpoint := range rawEndpoints {
+		endpoint, err := url.Parse(rawEndpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint %q: %w", rawEndpoint, err)
+		}
+		if (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint %q: expected an http:// or https:// URL", rawEndpoint)
+		}
+		if input.Username != "" {
+			endpoint.User = url.UserPassword(input.Username, input.Password)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ErrorResponse describes why a request failed
+type ErrorResponse struct {
+	StatusCode int             `json:"status_code,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Attributes json.RawMessage `json:"attributes,omitempty"`
+}
+
+// BulkError is a single failure reported by a bulk endpoint of the detection engine
+type BulkError struct {
+	StatusCode int    `json:"status_code,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Rules      []struct {
+		ID     string `json:"id,omitempty"`
+		RuleID string `json:"rule_id,omitempty"`
+		Name   string `json:"name,omitempty"`
+	} `json:"rules,omitempty"`
+}
+
+// FieldError is a validation failure of a single field of the request body
+type FieldError struct {
+	// Path is the dot separated path of the field in the request body, e.g. `threshold.value`
+	Path    string
+	Message string
+}
+
+// APIError is returned when Kibana answers a request with an unexpected status code
+type APIError struct {
+	StatusCode  int
+	Method      string
+	Path        string
+	Response    ErrorResponse
+	BulkErrors  []BulkError
+	FieldErrors []FieldError
+}
+
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s returned %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Response.Error != "" && e.Response.Error != http.StatusText(e.StatusCode) {
+		fmt.Fprintf(&b, " (%s)", e.Response.Error)
+	}
+	if e.Response.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Response.Message)
+	}
+	for _, bulkError := range e.BulkErrors {
+		fmt.Fprintf(&b, "\n- %s", bulkError.Message)
+		for _, rule := range bulkError.Rules {
+			fmt.Fprintf(&b, " (rule %s)", firstNonEmpty(rule.Name, rule.RuleID, rule.ID))
+		}
+	}
+	return b.String()
+}
+
+// IsNotFound reports whether the error is caused by a missing object
+func IsNotFound(err error) bool {
+	return hasStatusCode(err, http.StatusNotFound)
+}
+
+// IsConflict reports whether the error is caused by an object that already exists or was modified concurrently
+func IsConflict(err error) bool {
+	return hasStatusCode(err, http.StatusConflict)
+}
+
+// IsValidation reports whether Kibana rejected the request body
+func IsValidation(err error) bool {
+	return hasStatusCode(err, http.StatusBadRequest, http.StatusUnprocessableEntity)
+}
+
+// IsUnauthorized reports whether the credentials were rejected
+func IsUnauthorized(err error) bool {
+	return hasStatusCode(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether the user lacks the privileges for the request
+func IsForbidden(err error) bool {
+	return hasStatusCode(err, http.StatusForbidden)
+}
+
+func hasStatusCode(err error, statusCodes ...int) bool {
+	var apiError *APIError
+	if !errors.As(err, &apiError) {
+		return false
+	}
+	return Contains(statusCodes, apiError.StatusCode)
+}
+
+// newAPIError decodes the response body of a failed request
+func newAPIError(method, path string, statusCode int, body []byte) *APIError {
+	apiError := &APIError{
+		StatusCode: statusCode,
+		Method:     method,
+		Path:       path,
+	}
+	if err := json.Unmarshal(body, &apiError.Response); err != nil {
+		apiError.Response.Message = strings.TrimSpace(string(body))
+		return apiError
+	}
+
+	var attributes struct {
+		Errors []BulkError `json:"errors,omitempty"`
+	}
+	if len(apiError.Response.Attributes) > 0 && json.Unmarshal(apiError.Response.Attributes, &attributes) == nil {
+		apiError.BulkErrors = attributes.Errors
+	}
+
+	apiError.FieldErrors = parseFieldErrors(apiError.Response.Message)
+	for _, bulkError := range apiError.BulkErrors {
+		apiError.FieldErrors = append(apiError.FieldErrors, parseFieldErrors(bulkError.Message)...)
+	}
+	return apiError
+}
+
+// requestBodyValidation matches the validation messages of the Kibana request schemas, e.g.
+// `[request body]: threshold.value: Required, type: Invalid literal value`
+var requestBodyValidation = regexp.MustCompile(`^\[request (?:body|query)\]:\s*(.*)$`)
+
+// fieldValidation matches a single `<path>: <message>` entry of a validation message
+var fieldValidation = regexp.MustCompile(`^([A-Za-z_][\w.\[\]]*):\s*(.+)$`)
+
+// invalidValueSupplied matches the io-ts style `Invalid value "x" supplied to "threshold,value"`
+var invalidValueSupplied = regexp.MustCompile(`Invalid value "[^"]*" supplied to "([^"]+)"`)
+
+func parseFieldErrors(message string) []FieldError {
+	var fieldErrors []FieldError
+	if match := requestBodyValidation.FindStringSubmatch(message); match != nil {
+		for _, entry := range strings.Split(match[1], ", ") {
+			if field := fieldValidation.FindStringSubmatch(strings.TrimSpace(entry)); field != nil {
+				fieldErrors = append(fieldErrors, FieldError{Path: field[1], Message: field[2]})
+			}
+		}
+		return fieldErrors
+	}
+	for _, match := range invalidValueSupplied.FindAllStringSubmatch(message, -1) {
+		fieldErrors = append(fieldErrors, FieldError{
+			Path:    strings.ReplaceAll(match[1], ",", "."),
+			Message: match[0],
+		})
+	}
+	return fieldErrors
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package helpers
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestNewAPIError(t *testing.T) {
+	validation := newAPIError("POST", "/api/detection_engine/rules", 400,
+		[]byte(`{"statusCode":400,"error":"Bad Request","message":"[request body]: threshold.value: Required, type: Invalid literal value, expected \"eql\""}`))
+	if !IsValidation(validation) || IsNotFound(validation) {
+		t.Errorf("expected a validation error, got %d", validation.StatusCode)
+	}
+	if len(validation.FieldErrors) != 2 || validation.FieldErrors[0].Path != "threshold.value" || validation.FieldErrors[1].Path != "type" {
+		t.Errorf("unexpected field errors: %+v", validation.FieldErrors)
+	}
+
+	bulk := newAPIError("POST", "/api/detection_engine/rules/_bulk_action", 500,
+		[]byte(`{"message":"Bulk edit failed","status_code":500,"attributes":{"errors":[{"message":"Invalid value \"x\" supplied to \"threshold,value\"","status_code":400,"rules":[{"id":"1","name":"My rule"}]}]}}`))
+	if len(bulk.BulkErrors) != 1 || bulk.BulkErrors[0].Rules[0].Name != "My rule" {
+		t.Errorf("unexpected bulk errors: %+v", bulk.BulkErrors)
+	}
+	if len(bulk.FieldErrors) != 1 || bulk.FieldErrors[0].Path != "threshold.value" {
+		t.Errorf("unexpected field errors: %+v", bulk.FieldErrors)
+	}
+
+	notFound := fmt.Errorf("wrapped: %w", newAPIError("GET", "/api/detection_engine/rules?id=1", 404,
+		[]byte(`{"message":"id: \"1\" not found","status_code":404}`)))
+	if !IsNotFound(notFound) {
+		t.Error("expected a wrapped 404 to be recognised as not found")
+	}
+
+	unauthorized := newAPIError("GET", "/api/status", 401, []byte(`not json`))
+	if !IsUnauthorized(unauthorized) || unauthorized.Response.Message != "not json" {
+		t.Errorf("unexpected error: %+v", unauthorized)
+	}
+}
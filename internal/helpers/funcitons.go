@@ -4,9 +4,11 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"strings"
 )
 
-func contains[K comparable](s []K, item K) bool {
+// Contains reports whether the slice holds the item
+func Contains[K comparable](s []K, item K) bool {
 	for _, v := range s {
 		if v == item {
 			return true
@@ -139,3 +141,48 @@ func CheckIfKeyExists(values interface{}, key string) bool {
 	_, ok := values_map[key]
 	return ok
 }
+
+// GetJSONPath returns the value at a slash separated path of a decoded JSON object, e.g. `threshold/value`
+func GetJSONPath(object map[string]interface{}, path string) (interface{}, bool) {
+	keys := strings.Split(path, "/")
+	var current interface{} = object
+	for _, key := range keys {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// SetJSONPath sets the value at a slash separated path of a decoded JSON object, creating missing objects
+func SetJSONPath(object map[string]interface{}, path string, value interface{}) {
+	keys := strings.Split(path, "/")
+	current := object
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[key] = next
+		}
+		current = next
+	}
+	current[keys[len(keys)-1]] = value
+}
+
+// DeleteJSONPath removes the value at a slash separated path of a decoded JSON object
+func DeleteJSONPath(object map[string]interface{}, path string) {
+	parent := object
+	if i := strings.LastIndex(path, "/"); i >= 0 {
+		value, _ := GetJSONPath(object, path[:i])
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		parent, path = m, path[i+1:]
+	}
+	delete(parent, path)
+}
@@ -0,0 +1,139 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// maxLoggedBodyLength is the number of bytes of a request or response body written to the trace log
+const maxLoggedBodyLength = 4096
+
+// redacted replaces secrets in the logs
+const redacted = "***"
+
+// sensitiveKeys are JSON keys whose values are never logged, e.g. connector secrets
+var sensitiveKeys = []string{"secrets", "password", "token", "apikey", "api_key", "authorization", "secret", "private_key"}
+
+// sensitiveHeaders are request headers whose values are never logged
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// logRequest writes a request to the debug log, and its headers and body to the trace log. The values of
+// the headers configured by the user are redacted, they often carry gateway keys or tenant tokens.
+func logRequest(ctx context.Context, req *http.Request, body []byte, attempt int, userHeaders map[string]string) {
+	tflog.Debug(ctx, "Sending request to Kibana", map[string]interface{}{
+		"method":  req.Method,
+		"url":     redactURL(req.URL),
+		"attempt": attempt + 1,
+	})
+	tflog.Trace(ctx, "Kibana request details", map[string]interface{}{
+		"method":  req.Method,
+		"url":     redactURL(req.URL),
+		"headers": redactHeaders(req.Header, userHeaders),
+		"body":    redactBody(body),
+	})
+}
+
+// logResponse writes a response to the debug log, and its body to the trace log
+func logResponse(ctx context.Context, req *http.Request, resp *http.Response, body []byte, latency time.Duration) {
+	tflog.Debug(ctx, "Received response from Kibana", map[string]interface{}{
+		"method":     req.Method,
+		"url":        redactURL(req.URL),
+		"status":     resp.StatusCode,
+		"latency_ms": latency.Milliseconds(),
+	})
+	tflog.Trace(ctx, "Kibana response details", map[string]interface{}{
+		"method": req.Method,
+		"url":    redactURL(req.URL),
+		"status": resp.StatusCode,
+		"body":   redactBody(body),
+	})
+}
+
+// logRequestError writes a request which did not receive a response to the debug log
+func logRequestError(ctx context.Context, req *http.Request, err error, latency time.Duration) {
+	tflog.Debug(ctx, "Request to Kibana failed", map[string]interface{}{
+		"method":     req.Method,
+		"url":        redactURL(req.URL),
+		"error":      err.Error(),
+		"latency_ms": latency.Milliseconds(),
+	})
+}
+
+// redactURL returns the URL without its user info, as neither the user name nor the password are logged
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	redactedURL.User = nil
+	return redactedURL.String()
+}
+
+// redactHeaders masks the sensitive headers and the headers configured by the user
+func redactHeaders(header http.Header, userHeaders map[string]string) map[string]string {
+	result := make(map[string]string, len(header))
+	for name, values := range header {
+		value := strings.Join(values, ", ")
+		for _, sensitive := range sensitiveHeaders {
+			if strings.EqualFold(name, sensitive) {
+				value = redacted
+			}
+		}
+		for userHeader := range userHeaders {
+			if strings.EqualFold(name, userHeader) {
+				value = redacted
+			}
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// redactBody masks sensitive values of a JSON body and truncates it for the logs
+func redactBody(body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err == nil {
+		if redactedBody, err := json.Marshal(redactValue(value)); err == nil {
+			body = redactedBody
+		}
+	}
+	if len(body) > maxLoggedBodyLength {
+		return fmt.Sprintf("%s... (%d bytes truncated)", body[:maxLoggedBodyLength], len(body)-maxLoggedBodyLength)
+	}
+	return string(body)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSensitiveKey(key) {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if key == sensitive {
+			return true
+		}
+	}
+	return false
+}
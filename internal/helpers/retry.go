@@ -0,0 +1,82 @@
+package helpers
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryWaitMin is the base of the exponential backoff between retries
+const retryWaitMin = 500 * time.Millisecond
+
+// isIdempotent reports whether a request can be sent again without side effects
+func isIdempotent(method string) bool {
+	return method == "GET" || method == "PUT" || method == "DELETE"
+}
+
+// shouldRetryStatus reports whether a response status is transient and the request should be retried.
+// 429 and 503 are rejected before Kibana processed the request, so they are retried for every method,
+// gateway errors only for idempotent requests.
+func shouldRetryStatus(method string, statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(method)
+	}
+	return false
+}
+
+// retryWait returns how long to wait before the next attempt. A Retry-After header is honoured
+// as it is, otherwise an exponential backoff with jitter is used, capped at waitMax.
+func retryWait(attempt int, resp *http.Response, waitMax time.Duration) time.Duration {
+	if resp != nil {
+		if wait, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return wait
+		}
+	}
+
+	backoff := retryWaitMin << attempt
+	if backoff <= 0 || backoff > waitMax {
+		backoff = waitMax
+	}
+	// Equal jitter: wait at least half of the backoff so retries still spread out
+	half := backoff / 2
+	if half <= 0 {
+		return backoff
+	}
+	return half + time.Duration(rand.Int63n(int64(half)))
+}
+
+// exceedsDeadline reports whether waiting for the given duration would pass the deadline of the context
+func exceedsDeadline(ctx context.Context, wait time.Duration) bool {
+	deadline, ok := ctx.Deadline()
+	return ok && time.Now().Add(wait).After(deadline)
+}
+
+// sleepContext waits for the given duration or until the context is done
+func sleepContext(ctx context.Context, wait time.Duration) error {
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
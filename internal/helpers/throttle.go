@@ -0,0 +1,49 @@
+package helpers
+
+import (
+	"context"
+
+	"golang.org/x/time/rate"
+)
+
+// throttle limits the rate and the number of concurrent requests sent to Kibana. It is shared
+// by all copies of a client, so the limits apply to every resource and data source of a provider.
+type throttle struct {
+	limiter   *rate.Limiter
+	semaphore chan struct{}
+}
+
+// newThrottle returns a throttle, a limit of zero disables it
+func newThrottle(requestsPerSecond float64, concurrentRequests int) *throttle {
+	t := &throttle{}
+	if requestsPerSecond > 0 {
+		t.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+	}
+	if concurrentRequests > 0 {
+		t.semaphore = make(chan struct{}, concurrentRequests)
+	}
+	return t
+}
+
+// acquire blocks until a request may be sent, the returned function must be called once it completed
+func (t *throttle) acquire(ctx context.Context) (func(), error) {
+	if t.semaphore != nil {
+		select {
+		case t.semaphore <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if t.semaphore != nil {
+			<-t.semaphore
+		}
+	}
+	if t.limiter != nil {
+		if err := t.limiter.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
@@ -0,0 +1,63 @@
+package helpers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottleConcurrency(t *testing.T) {
+	throttle := newThrottle(0, 2)
+	var inFlight, maxInFlight int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := throttle.acquire(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			current := atomic.AddInt32(&inFlight, 1)
+			for {
+				observed := atomic.LoadInt32(&maxInFlight)
+				if current <= observed || atomic.CompareAndSwapInt32(&maxInFlight, observed, current) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&inFlight, -1)
+			release()
+		}()
+	}
+	wg.Wait()
+	if maxInFlight > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", maxInFlight)
+	}
+}
+
+func TestThrottleRate(t *testing.T) {
+	throttle := newThrottle(50, 0)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		release, err := throttle.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected 6 requests at 50 per second to take at least 100ms, took %s", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	full := newThrottle(0, 1)
+	full.acquire(context.Background())
+	if _, err := full.acquire(ctx); err == nil {
+		t.Error("expected a cancelled context to stop waiting for a slot")
+	}
+}
@@ -0,0 +1,60 @@
+package helpers
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// newTLSConfig builds the TLS configuration used to connect to Kibana
+func newTLSConfig(input *NewClientInput) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: input.InsecureSkipVerify,
+	}
+
+	if input.CACertificate != "" {
+		caPEM, err := loadPEM(input.CACertificate)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA certificate: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("CA certificate does not contain a valid PEM encoded certificate")
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if input.ClientCertificate != "" || input.ClientKey != "" {
+		if input.ClientCertificate == "" || input.ClientKey == "" {
+			return nil, fmt.Errorf("both a client certificate and a client key are required for mutual TLS")
+		}
+		certPEM, err := loadPEM(input.ClientCertificate)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client certificate: %w", err)
+		}
+		keyPEM, err := loadPEM(input.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client key: %w", err)
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// loadPEM returns the given value if it is an inline PEM block, otherwise it is read as a file path
+func loadPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
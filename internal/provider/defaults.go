@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"terraform-provider-elastic-siem/internal/helpers"
+	"terraform-provider-elastic-siem/internal/provider/transferobjects"
+)
+
+// providerData is passed by the provider to its resources and data sources
+type providerData struct {
+	client   *helpers.Client
+	defaults resourceDefaults
+}
+
+// resourceDefaults are the provider wide defaults merged into the objects sent to Kibana
+type resourceDefaults struct {
+	rule ruleDefaults
+	// tags are added to every rule, exception container and exception item
+	tags []string
+}
+
+type ruleDefaults struct {
+	author     []string
+	license    string
+	index      []string
+	interval   string
+	from       string
+	maxSignals int
+	tags       []string
+}
+
+// RuleDefaultsModel describes the provider `rule_defaults` block
+type RuleDefaultsModel struct {
+	Author     types.List   `tfsdk:"author"`
+	License    types.String `tfsdk:"license"`
+	Index      types.List   `tfsdk:"index"`
+	Interval   types.String `tfsdk:"interval"`
+	From       types.String `tfsdk:"from"`
+	MaxSignals types.Int64  `tfsdk:"max_signals"`
+	Tags       types.List   `tfsdk:"tags"`
+}
+
+// newResourceDefaults reads the defaults from the provider configuration
+func newResourceDefaults(ctx context.Context, model *RuleDefaultsModel, defaultTags types.List) (resourceDefaults, diag.Diagnostics) {
+	var defaults resourceDefaults
+	var diags diag.Diagnostics
+	diags.Append(defaultTags.ElementsAs(ctx, &defaults.tags, false)...)
+	if model == nil {
+		return defaults, diags
+	}
+	diags.Append(model.Author.ElementsAs(ctx, &defaults.rule.author, false)...)
+	diags.Append(model.Index.ElementsAs(ctx, &defaults.rule.index, false)...)
+	diags.Append(model.Tags.ElementsAs(ctx, &defaults.rule.tags, false)...)
+	defaults.rule.license = model.License.ValueString()
+	defaults.rule.interval = model.Interval.ValueString()
+	defaults.rule.from = model.From.ValueString()
+	defaults.rule.maxSignals = int(model.MaxSignals.ValueInt64())
+	return defaults, diags
+}
+
+// applyToRule fills the fields the rule does not set with the defaults and adds the default tags
+func (d resourceDefaults) applyToRule(rule *transferobjects.DetectionRule) {
+	if len(rule.Author) == 0 {
+		rule.Author = d.rule.author
+	}
+	if rule.License == "" {
+		rule.License = d.rule.license
+	}
+	if len(rule.Index) == 0 && rule.DataViewID == "" && rule.Type != "esql" && rule.Type != "machine_learning" {
+		rule.Index = d.rule.index
+	}
+	if rule.Interval == "" {
+		rule.Interval = d.rule.interval
+	}
+	if rule.From == "" {
+		rule.From = d.rule.from
+	}
+	if rule.MaxSignals == 0 {
+		rule.MaxSignals = d.rule.maxSignals
+	}
+	rule.Tags = mergeTags(rule.Tags, d.rule.tags, d.tags)
+}
+
+// applyToTags adds the default tags to the tags of an exception container or item
+func (d resourceDefaults) applyToTags(tags []string) []string {
+	return mergeTags(tags, d.tags)
+}
+
+// mergeTags concatenates the tags without duplicates, keeping the order they first appear in
+func mergeTags(tagLists ...[]string) []string {
+	var result []string
+	seen := make(map[string]bool)
+	for _, tags := range tagLists {
+		for _, tag := range tags {
+			if !seen[tag] {
+				seen[tag] = true
+				result = append(result, tag)
+			}
+		}
+	}
+	return result
+}
+
+// mergeRuleContent parses the rule content and merges the provider defaults and the exception containers
+// attached by the resource into it, a legacy rule level throttle is moved to the actions. It returns the rule to send to Kibana, the keys to remove from the
+// request body and the merged content as JSON.
+func mergeRuleContent(ruleContent string, defaults resourceDefaults, exceptionLists []transferobjects.ExceptionListItem) (*transferobjects.DetectionRule, []string, string, error) {
+	var body *transferobjects.DetectionRule
+	var itemsToRemove []string
+	if err := helpers.ObjectFronJSON(ruleContent, &body); err != nil {
+		return nil, nil, "", err
+	}
+	if body == nil {
+		body = &transferobjects.DetectionRule{}
+	}
+	defaults.applyToRule(body)
+	body.ExceptionsList = mergeExceptionLists(body.ExceptionsList, exceptionLists)
+	migrateRuleThrottle(body)
+	normalizeRuleActions(body)
+
+	if len(body.Threshold.Field) == 0 {
+		itemsToRemove = append(itemsToRemove, "threshold")
+	}
+
+	effective, err := json.Marshal(body)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	if err := helpers.RemoveKeysFromJSONObjectBytes(&effective, itemsToRemove); err != nil {
+		return nil, nil, "", err
+	}
+	return body, itemsToRemove, string(effective), nil
+}
@@ -0,0 +1,75 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMergeRuleContent(t *testing.T) {
+	defaults := resourceDefaults{
+		rule: ruleDefaults{
+			author:     []string{"Security Team"},
+			license:    "Elastic License v2",
+			index:      []string{"logs-*"},
+			interval:   "5m",
+			from:       "now-6m",
+			maxSignals: 100,
+			tags:       []string{"Team: SOC"},
+		},
+		tags: []string{"managed-by: terraform", "Team: SOC"},
+	}
+
+	body, itemsToRemove, effective, err := mergeRuleContent(`{"name":"My rule","type":"query","interval":"1m","tags":["Linux"]}`, defaults, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Interval != "1m" || body.From != "now-6m" || body.MaxSignals != 100 || body.License != "Elastic License v2" {
+		t.Errorf("expected the rule to keep its own values and use the defaults for the others, got %+v", body)
+	}
+	if !reflect.DeepEqual(body.Tags, []string{"Linux", "Team: SOC", "managed-by: terraform"}) {
+		t.Errorf("unexpected tags: %v", body.Tags)
+	}
+	if !reflect.DeepEqual(itemsToRemove, []string{"threshold"}) {
+		t.Errorf("expected the empty threshold to be removed, got %v", itemsToRemove)
+	}
+	_, _, again, err := mergeRuleContent(`{"tags":["Linux"],"type":"query","interval":"1m","name":"My rule"}`, defaults, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if effective != again {
+		t.Errorf("expected the effective content not to depend on the key order:\n%s\n%s", effective, again)
+	}
+
+	esql, _, _, err := mergeRuleContent(`{"type":"esql","query":"from logs-*"}`, defaults, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(esql.Index) != 0 {
+		t.Errorf("expected no default index for ES|QL rules, got %v", esql.Index)
+	}
+
+	threatMatch, _, effective, err := mergeRuleContent(`{"type":"threat_match","data_view_id":"logs-dv","threat_language":"kuery"}`, defaults, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if threatMatch.DataViewID != "logs-dv" || threatMatch.ThreatLanguage != "kuery" || len(threatMatch.Index) != 0 {
+		t.Errorf("expected the data view and threat language to be kept without a default index, got %+v", threatMatch)
+	}
+	if !strings.Contains(effective, `"data_view_id":"logs-dv"`) || !strings.Contains(effective, `"threat_language":"kuery"`) {
+		t.Errorf("expected the effective content to keep the data view and threat language, got %s", effective)
+	}
+
+	if _, _, _, err := mergeRuleContent(`{"name":`, defaults, nil); err == nil {
+		t.Error("expected invalid JSON to be rejected")
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	if tags := mergeTags(nil, nil); tags != nil {
+		t.Errorf("expected no tags, got %v", tags)
+	}
+	if tags := (resourceDefaults{tags: []string{"b", "c"}}).applyToTags([]string{"a", "b"}); !reflect.DeepEqual(tags, []string{"a", "b", "c"}) {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+}
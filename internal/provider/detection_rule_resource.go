@@ -2,13 +2,21 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
 	"terraform-provider-elastic-siem/internal/helpers"
 	"terraform-provider-elastic-siem/internal/provider/transferobjects"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -20,6 +28,8 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces
 var _ resource.Resource = &DetectionRuleResource{}
 var _ resource.ResourceWithImportState = &DetectionRuleResource{}
+var _ resource.ResourceWithModifyPlan = &DetectionRuleResource{}
+var _ resource.ResourceWithValidateConfig = &DetectionRuleResource{}
 
 func NewDetectionRuleResource() resource.Resource {
 	return &DetectionRuleResource{}
@@ -27,16 +37,37 @@ func NewDetectionRuleResource() resource.Resource {
 
 // DetectionRuleResource defines the resource implementation.
 type DetectionRuleResource struct {
-	client *helpers.Client
+	client   *helpers.Client
+	defaults resourceDefaults
 }
 
 // DetectionRuleResourceModel describes the resource data model.
 type DetectionRuleResourceModel struct {
-	RuleContent              types.String `tfsdk:"rule_content"`
-	ExceptionContainerId     types.String `tfsdk:"exception_container_id"`
-	ExceptionContainerListId types.String `tfsdk:"exception_container_list_id"`
-	ExceptionType            types.String `tfsdk:"exception_type"`
-	Id                       types.String `tfsdk:"id"`
+	RuleContent              JSONContentValue     `tfsdk:"rule_content"`
+	EffectiveRuleContent     types.String         `tfsdk:"effective_rule_content"`
+	ExceptionContainerId     types.String         `tfsdk:"exception_container_id"`
+	ExceptionContainerListId types.String         `tfsdk:"exception_container_list_id"`
+	ExceptionType            types.String         `tfsdk:"exception_type"`
+	ExceptionLists           []ExceptionListModel `tfsdk:"exception_list"`
+	Enabled                  types.Bool           `tfsdk:"enabled"`
+	SpaceId                  types.String         `tfsdk:"space_id"`
+	Id                       types.String         `tfsdk:"id"`
+	Timeouts                 timeouts.Value       `tfsdk:"timeouts"`
+}
+
+// exceptionLists returns the exception containers attached to the rule, in the order of the blocks
+// followed by the container of the deprecated attributes. It reports whether all values are known.
+func (m *DetectionRuleResourceModel) exceptionLists() ([]transferobjects.ExceptionListItem, bool) {
+	lists := m.ExceptionLists
+	if !m.ExceptionContainerId.IsNull() && !m.ExceptionContainerListId.IsNull() && !m.ExceptionType.IsNull() {
+		lists = append(append([]ExceptionListModel{}, lists...), ExceptionListModel{
+			Id:            m.ExceptionContainerId,
+			ListId:        m.ExceptionContainerListId,
+			Type:          m.ExceptionType,
+			NamespaceType: types.StringValue("single"),
+		})
+	}
+	return exceptionListItems(lists)
 }
 
 func (r *DetectionRuleResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -46,28 +77,44 @@ func (r *DetectionRuleResource) Metadata(ctx context.Context, req resource.Metad
 func (r *DetectionRuleResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		// This description is used by the documentation generator and the language server.
-		MarkdownDescription: "Detection rule resource",
+		MarkdownDescription: "Detection rule resource. Rules are imported by `<id>`, `rule_id:<rule_id>`, `<space_id>/<id>` or `<space_id>/rule_id:<rule_id>`.",
 
 		Attributes: map[string]schema.Attribute{
 			"rule_content": schema.StringAttribute{
-				MarkdownDescription: "The content of the rule (JSON encoded string)",
+				MarkdownDescription: "The content of the rule (JSON encoded string). Formatting, key order and values equal to the Kibana defaults are not considered a change.",
+				CustomType:          ruleContentType,
 				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					suppressEquivalentJSONContent(),
+				},
+			},
+			"effective_rule_content": schema.StringAttribute{
+				MarkdownDescription: "The content of the rule sent to Kibana, with the provider `rule_defaults` and `default_tags` merged in and the `enabled` attribute applied (JSON encoded string)",
+				Computed:            true,
 			},
 			"exception_container_id": schema.StringAttribute{
-				MarkdownDescription: "The container ID that should be used for exceptions for this item (overrides id in rule_content)",
+				MarkdownDescription: "The container ID that should be used for exceptions for this item (added to the `exceptions_list` of rule_content)",
 				Optional:            true,
+				DeprecationMessage:  "Use the `exception_list` block instead",
 			},
 			"exception_container_list_id": schema.StringAttribute{
-				MarkdownDescription: "The container list ID that should be used for exceptions for this item (overrides id in rule_content)",
+				MarkdownDescription: "The container list ID that should be used for exceptions for this item (added to the `exceptions_list` of rule_content)",
 				Optional:            true,
+				DeprecationMessage:  "Use the `exception_list` block instead",
 			},
 			"exception_type": schema.StringAttribute{
 				MarkdownDescription: "The type that should be used for exceptions for this item (defaults to `detection`)",
+				DeprecationMessage:  "Use the `exception_list` block instead",
 				Optional:            true,
 				Computed:            true,
 				Default:             stringdefault.StaticString("detection"),
 				Validators:          []validator.String{stringvalidator.OneOf("detection", "endpoint")},
 			},
+			"enabled": schema.BoolAttribute{
+				MarkdownDescription: "Whether the rule runs, overrides `enabled` of rule_content. A change is applied with a bulk action instead of an update of the rule, so the rule content and its revision stay as they are. Left to rule_content when not set.",
+				Optional:            true,
+			},
+			"space_id": spaceIdAttribute(),
 			"id": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Rule identifier (in UUID format)",
@@ -76,6 +123,15 @@ func (r *DetectionRuleResource) Schema(ctx context.Context, req resource.SchemaR
 				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"exception_list": exceptionListBlock(),
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -85,57 +141,114 @@ func (r *DetectionRuleResource) Configure(ctx context.Context, req resource.Conf
 		return
 	}
 
-	client, ok := req.ProviderData.(*helpers.Client)
+	data, ok := req.ProviderData.(*providerData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = data.client
+	r.defaults = data.defaults
 }
 
-func (r *DetectionRuleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	var data *DetectionRuleResourceModel
-	var body *transferobjects.DetectionRule
-	var itemsToRemote []string
+// ValidateConfig checks the rule content against the keys its rule type requires and supports, so an
+// invalid rule fails the plan instead of being rejected by Kibana during apply
+func (r *DetectionRuleResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var ruleContent JSONContentValue
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("rule_content"), &ruleContent)...)
 
-	// Read Terraform plan data into the model
+	if resp.Diagnostics.HasError() || ruleContent.IsNull() || ruleContent.IsUnknown() {
+		return
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal([]byte(ruleContent.ValueString()), &content); err != nil {
+		// Reported by the attribute type
+		return
+	}
+	validateRuleContent(content, path.Root("rule_content"), &resp.Diagnostics)
+}
+
+func (r *DetectionRuleResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check on destroy or before the provider is configured
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	var data *DetectionRuleResourceModel
+	var configLists []ExceptionListModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("exception_list"), &configLists)...)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// Process the rule content
-	err := helpers.ObjectFronJSON(data.RuleContent.ValueString(), &body)
+	if len(configLists) > 0 {
+		planExceptionListIds(ctx, r.client, data.SpaceId.ValueString(), configLists, data.ExceptionLists, &resp.Diagnostics)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("exception_list"), data.ExceptionLists)...)
+	}
+
+	if resp.Diagnostics.HasError() || data.RuleContent.IsUnknown() {
+		return
+	}
+
+	exceptionLists, known := data.exceptionLists()
+	body, _, effectiveRuleContent, err := mergeDetectionRuleContent(data, r.defaults, exceptionLists)
 	if err != nil {
-		resp.Diagnostics.AddError("Parser Error", fmt.Sprintf("Unable to parse file, got error: %s", err))
+		// Parser errors are reported during apply
 		return
 	}
+	checkRuleCapabilities(ctx, r.client, body, path.Root("rule_content"), &resp.Diagnostics)
 
-	if len(body.Threshold.Field) == 0 {
-		itemsToRemote = append(itemsToRemote, "threshold")
+	if !known || data.Enabled.IsUnknown() {
+		// An exception container created in the same apply has no ID yet, or the enabled state is not known
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("effective_rule_content"), types.StringUnknown())...)
+		return
 	}
+	// The merged content is part of the plan, so a change of the provider defaults updates the rule
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("effective_rule_content"), effectiveRuleContent)...)
+}
+
+func (r *DetectionRuleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *DetectionRuleResourceModel
 
-	if !data.ExceptionContainerId.IsNull() && !data.ExceptionContainerListId.IsNull() && !data.ExceptionType.IsNull() {
-		var exceptionListItem transferobjects.ExceptionListItem
-		exceptionListItem.ListID = data.ExceptionContainerListId.ValueString()
-		exceptionListItem.ID = data.ExceptionContainerId.ValueString()
-		exceptionListItem.NamespaceType = "single"
-		exceptionListItem.Type = data.ExceptionType.ValueString()
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-		body.ExceptionsList = append(body.ExceptionsList, exceptionListItem)
+	// Process the rule content
+	resolveExceptionListIds(ctx, r.client, data.SpaceId.ValueString(), data.ExceptionLists, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	exceptionLists, _ := data.exceptionLists()
+	body, itemsToRemote, effectiveRuleContent, err := mergeDetectionRuleContent(data, r.defaults, exceptionLists)
+	if err != nil {
+		resp.Diagnostics.AddError("Parser Error", fmt.Sprintf("Unable to parse file, got error: %s", err))
+		return
 	}
+	data.EffectiveRuleContent = types.StringValue(effectiveRuleContent)
 
 	// Create the rule through API
 	var response transferobjects.DetectionRuleResponse
-	if err := r.client.Post("/detection_engine/rules", body, &response, itemsToRemote); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: \n%s", err))
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Post(ctx, "/detection_engine/rules", body, &response, itemsToRemote); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Root("rule_content"))
 		return
 	}
 
@@ -156,11 +269,48 @@ func (r *DetectionRuleResource) Read(ctx context.Context, req resource.ReadReque
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Get the rule through the API
-	var response transferobjects.DetectionRuleResponse
-	path := fmt.Sprintf("/detection_engine/rules?id=%s", data.Id.ValueString())
-	if err := r.client.Get(path, &response); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: %s", err))
+	apiPath := fmt.Sprintf("/detection_engine/rules?id=%s", data.Id.ValueString())
+	response, err := r.client.WithSpace(data.SpaceId.ValueString()).GetString(ctx, apiPath)
+	if err != nil {
+		if removeMissingResource(ctx, err, resp) {
+			return
+		}
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
+		return
+	}
+
+	if !data.Enabled.IsNull() {
+		var live transferobjects.DetectionRule
+		if err := json.Unmarshal([]byte(response), &live); err != nil {
+			resp.Diagnostics.AddError("Parser Error", fmt.Sprintf("Unable to parse the rule read from Kibana, got error: %s", err))
+			return
+		}
+		data.Enabled = types.BoolValue(live.Enabled == nil || *live.Enabled)
+	}
+
+	if data.RuleContent.IsNull() {
+		// An imported rule has no content yet, it is taken from Kibana
+		ruleContent, effectiveRuleContent, err := importedRuleContent([]byte(response), r.defaults)
+		if err != nil {
+			resp.Diagnostics.AddError("Parser Error", fmt.Sprintf("Unable to parse the rule read from Kibana, got error: %s", err))
+			return
+		}
+		data.RuleContent = ruleContentType.NewValue(ruleContent)
+		data.EffectiveRuleContent = types.StringValue(effectiveRuleContent)
+		if data.ExceptionType.IsNull() {
+			data.ExceptionType = types.StringValue("detection")
+		}
+	} else if err := detectRuleDrift(ctx, data, []byte(response)); err != nil {
+		// Changes made outside of Terraform, e.g. in the Kibana UI, are written to the state so the plan shows them
+		resp.Diagnostics.AddError("Parser Error", fmt.Sprintf("Unable to compare the rule with Kibana, got error: %s", err))
 		return
 	}
 
@@ -169,36 +319,48 @@ func (r *DetectionRuleResource) Read(ctx context.Context, req resource.ReadReque
 }
 
 func (r *DetectionRuleResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var data *DetectionRuleResourceModel
-	var body *transferobjects.DetectionRule
-	var itemsToRemote []string
+	var data, state *DetectionRuleResourceModel
 
 	// Read Terraform plan data into the model
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, &resp.Diagnostics)
+	defer cancel()
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	// Process the rule content
-	err := helpers.ObjectFronJSON(data.RuleContent.ValueString(), &body)
+	resolveExceptionListIds(ctx, r.client, data.SpaceId.ValueString(), data.ExceptionLists, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	exceptionLists, _ := data.exceptionLists()
+	body, itemsToRemote, effectiveRuleContent, err := mergeDetectionRuleContent(data, r.defaults, exceptionLists)
 	if err != nil {
 		resp.Diagnostics.AddError("Parser Error", fmt.Sprintf("Unable to parse file, got error: %s", err))
 		return
 	}
-
-	if len(body.Threshold.Field) == 0 {
-		itemsToRemote = append(itemsToRemote, "threshold")
-	}
-
-	if !data.ExceptionContainerId.IsNull() && !data.ExceptionContainerListId.IsNull() && !data.ExceptionType.IsNull() {
-		var exceptionListItem transferobjects.ExceptionListItem
-		exceptionListItem.ListID = data.ExceptionContainerListId.ValueString()
-		exceptionListItem.ID = data.ExceptionContainerId.ValueString()
-		exceptionListItem.NamespaceType = "single"
-		exceptionListItem.Type = data.ExceptionType.ValueString()
-
-		body.ExceptionsList = append(body.ExceptionsList, exceptionListItem)
+	data.EffectiveRuleContent = types.StringValue(effectiveRuleContent)
+
+	// A change of the enabled attribute alone is applied by a bulk action
+	contentChanged := !equalRuleContent(effectiveRuleContent, state.EffectiveRuleContent.ValueString(), !data.Enabled.IsNull())
+	if !contentChanged {
+		// Only the enabled state changed, a bulk action toggles the rule without updating it
+		if !data.Enabled.IsNull() && !data.Enabled.Equal(state.Enabled) {
+			if err := setRuleEnabled(ctx, r.client.WithSpace(data.SpaceId.ValueString()), data.Id.ValueString(), data.Enabled.ValueBool()); err != nil {
+				addClientErrorDiagnostic(&resp.Diagnostics, err, path.Root("enabled"))
+				return
+			}
+		}
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
 	}
 
 	if !helpers.CheckIfKeyExists(body, "rule_id") {
@@ -207,8 +369,8 @@ func (r *DetectionRuleResource) Update(ctx context.Context, req resource.UpdateR
 
 	// Create the rule through API
 	var response transferobjects.DetectionRuleResponse
-	if err := r.client.Put("/detection_engine/rules", body, &response, itemsToRemote); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: \n%s", err))
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Put(ctx, "/detection_engine/rules", body, &response, itemsToRemote); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Root("rule_content"))
 		return
 	}
 
@@ -226,14 +388,405 @@ func (r *DetectionRuleResource) Delete(ctx context.Context, req resource.DeleteR
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Get the rule through the API
-	path := fmt.Sprintf("/detection_engine/rules?id=%s", data.Id.ValueString())
-	if err := r.client.Delete(path); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: %s", err))
+	apiPath := fmt.Sprintf("/detection_engine/rules?id=%s", data.Id.ValueString())
+	deleteObject(ctx, r.client.WithSpace(data.SpaceId.ValueString()), apiPath, &resp.Diagnostics)
+}
+
+// mergeDetectionRuleContent merges the rule content of the model as mergeRuleContent does, with `enabled`
+// set to the `enabled` attribute when it is set, so the effective content holds the enabled state sent
+func mergeDetectionRuleContent(data *DetectionRuleResourceModel, defaults resourceDefaults, exceptionLists []transferobjects.ExceptionListItem) (*transferobjects.DetectionRule, []string, string, error) {
+	ruleContent := data.RuleContent.ValueString()
+	if !data.Enabled.IsNull() && !data.Enabled.IsUnknown() {
+		var content map[string]interface{}
+		if err := json.Unmarshal([]byte(ruleContent), &content); err != nil {
+			return nil, nil, "", err
+		}
+		if content == nil {
+			content = make(map[string]interface{})
+		}
+		content["enabled"] = data.Enabled.ValueBool()
+		contentBytes, err := json.Marshal(content)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		ruleContent = string(contentBytes)
+	}
+	return mergeRuleContent(ruleContent, defaults, exceptionLists)
+}
+
+// equalRuleContent reports whether two effective contents describe the same rule whatever their key order,
+// formatting and Kibana defaults, ignoring the enabled state if asked to
+func equalRuleContent(effectiveContent, otherContent string, ignoreEnabled bool) bool {
+	effective, err := normalizeRuleContent(effectiveContent)
+	if err != nil {
+		return false
+	}
+	other, err := normalizeRuleContent(otherContent)
+	if err != nil {
+		return false
+	}
+	if ignoreEnabled {
+		delete(effective, "enabled")
+		delete(other, "enabled")
+	}
+	return reflect.DeepEqual(effective, other)
+}
+
+// setRuleEnabled enables or disables a rule through a bulk action, which unlike an update keeps the rule
+// content and its revision
+func setRuleEnabled(ctx context.Context, client *helpers.Client, id string, enabled bool) error {
+	action := "disable"
+	if enabled {
+		action = "enable"
+	}
+	body := map[string]interface{}{
+		"action": action,
+		"ids":    []string{id},
+	}
+	return client.Post(ctx, "/detection_engine/rules/_bulk_action", body, nil, nil)
+}
+
+// checkRuleCapabilities reports features of a rule the target stack does not support. An outdated
+// version is an error as Kibana rejects the rule, a missing license only a warning as the rule is
+// created but the feature does not run. Features which cannot be checked are a warning too.
+func checkRuleCapabilities(ctx context.Context, client *helpers.Client, body *transferobjects.DetectionRule, attribute path.Path, diags *diag.Diagnostics) {
+	var features []helpers.Feature
+	switch body.Type {
+	case "new_terms":
+		features = append(features, helpers.FeatureNewTermsRules)
+	case "esql":
+		features = append(features, helpers.FeatureEsqlRules)
+	}
+	if body.AlertSuppression != nil {
+		features = append(features, helpers.FeatureAlertSuppression)
+	}
+	if len(body.ResponseActions) > 0 {
+		features = append(features, helpers.FeatureResponseActions)
+	}
+
+	if len(features) == 0 {
+		return
+	}
+	if err := client.EnsureServerInfo(ctx); err != nil {
+		diags.AddAttributeWarning(attribute, "Unverified Rule Features",
+			fmt.Sprintf("The version and license required by the rule features could not be checked: %s", err))
 		return
 	}
+	for _, feature := range features {
+		if err := client.CheckVersion(ctx, feature); err != nil {
+			diags.AddAttributeError(attribute, "Unsupported Rule Feature", err.Error())
+		} else if err := client.CheckLicense(ctx, feature); err != nil {
+			diags.AddAttributeWarning(attribute, "Unlicensed Rule Feature", err.Error())
+		}
+	}
+}
+
+// ruleServerFields are maintained by Kibana and never reported as drift
+var ruleServerFields = []string{
+	"id", "immutable", "version", "revision", "meta", "execution_summary", "rule_source",
+	"created_at", "created_by", "updated_at", "updated_by",
+}
+
+// ruleManagedFields are compared although the rule content does not set them. They are the keys an analyst
+// edits in Kibana that the provider sends, other keys only present in Kibana are set by the detection engine.
+var ruleManagedFields = []string{
+	"actions", "exceptions_list", "alert_suppression", "response_actions", "note",
+	"investigation_fields", "false_positives", "references", "building_block_type",
+	"severity_mapping", "risk_score_mapping", "rule_name_override", "timestamp_override",
 }
 
+// detectRuleDrift compares the rule read from Kibana with the effective content of the state. The keys the
+// effective content sets, the managed fields and the keys Kibana has a default for are compared, except for
+// the server fields and the values equal to the Kibana defaults. The live values of the keys that differ are
+// written to `effective_rule_content`, and to `rule_content` unless only the provider defaults or the
+// exception_list blocks set them. Both are left untouched when nothing changed.
+func detectRuleDrift(ctx context.Context, data *DetectionRuleResourceModel, live []byte) error {
+	if data.RuleContent.IsNull() {
+		return nil
+	}
+	effectiveContent := data.EffectiveRuleContent.ValueString()
+	if data.EffectiveRuleContent.IsNull() {
+		effectiveContent = data.RuleContent.ValueString()
+	}
+
+	liveRule, err := normalizeRuleContent(string(live))
+	if err != nil {
+		return err
+	}
+	effectiveRule, err := normalizeRuleContent(effectiveContent)
+	if err != nil {
+		return err
+	}
+
+	var effective, content map[string]interface{}
+	if err := json.Unmarshal([]byte(effectiveContent), &effective); err != nil {
+		return err
+	}
+	if err := json.Unmarshal([]byte(data.RuleContent.ValueString()), &content); err != nil {
+		return err
+	}
+
+	var drifted []string
+	for key := range mergeKeys(effectiveRule, liveRule) {
+		_, known := effective[key]
+		_, hasDefault := jsonContentDefaults[ruleContentKind][key]
+		if !known && !hasDefault && !helpers.Contains(ruleManagedFields, key) {
+			// Includes the `rule_id` Kibana generates when the content sets none
+			continue
+		}
+		if key == "enabled" && !data.Enabled.IsNull() {
+			// The enabled state is tracked by the `enabled` attribute
+			continue
+		}
+		if !reflect.DeepEqual(effectiveRule[key], liveRule[key]) {
+			drifted = append(drifted, key)
+		}
+	}
+	if len(drifted) == 0 {
+		return nil
+	}
+	sort.Strings(drifted)
+	tflog.Info(ctx, "Detection rule changed outside of Terraform", map[string]interface{}{
+		"id":   data.Id.ValueString(),
+		"keys": drifted,
+	})
+
+	var contentTags []interface{}
+	if tags, ok := content["tags"].([]interface{}); ok {
+		contentTags = tags
+	}
+	for _, key := range drifted {
+		liveValue, inLive := liveRule[key]
+		_, inEffective := effective[key]
+		if inLive {
+			effective[key] = liveValue
+		} else {
+			delete(effective, key)
+		}
+		if _, inContent := content[key]; !inContent && inEffective {
+			// Set by the provider defaults or the exception_list blocks, not by the rule content
+			continue
+		}
+		if !inLive {
+			delete(content, key)
+			continue
+		}
+		if key == "tags" {
+			// Tags added by the provider defaults are not part of the rule content
+			liveValue = withoutDefaultTags(liveValue, effectiveTags(effectiveContent), contentTags)
+		}
+		if key == "exceptions_list" {
+			// The exception containers attached by the resource are not part of the rule content
+			attached, _ := data.exceptionLists()
+			liveValue = withoutAttachedExceptionLists(liveValue, attached, content[key])
+		}
+		content[key] = liveValue
+	}
+
+	effectiveBytes, err := json.Marshal(effective)
+	if err != nil {
+		return err
+	}
+	contentBytes, err := json.Marshal(content)
+	if err != nil {
+		return err
+	}
+	data.EffectiveRuleContent = types.StringValue(string(effectiveBytes))
+	data.RuleContent = ruleContentType.NewValue(string(contentBytes))
+	return nil
+}
+
+// normalizeRuleContent decodes a rule for the drift detection. The keys that do not change the rule Kibana
+// stores are dropped as for the comparison of `rule_content`, as are the empty values nested in objects,
+// and the actions are normalized. All other keys are kept, whether the provider models them or not.
+func normalizeRuleContent(content string) (map[string]interface{}, error) {
+	rule, err := normalizeJSONContent(content, ruleContentKind)
+	if err != nil {
+		return nil, err
+	}
+	if actions, ok := rule["actions"]; ok {
+		var normalized transferobjects.DetectionRule
+		if err := convertJSON(actions, &normalized.Actions); err != nil {
+			return nil, err
+		}
+		normalizeRuleActions(&normalized)
+		if err := convertJSON(normalized.Actions, &actions); err != nil {
+			return nil, err
+		}
+		rule["actions"] = actions
+	}
+	for key, value := range rule {
+		rule[key] = withoutEmptyJSONValues(value)
+	}
+	return rule, nil
+}
+
+// withoutEmptyJSONValues drops the null and empty values of the objects nested in a value
+func withoutEmptyJSONValues(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			if !isEmptyJSONValue(item) {
+				result[key] = withoutEmptyJSONValues(item)
+			}
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(typed))
+		for i, item := range typed {
+			result[i] = withoutEmptyJSONValues(item)
+		}
+		return result
+	}
+	return value
+}
+
+// normalizeRule decodes a rule through transferobjects.DetectionRule, so the fields the provider does not
+// send and the empty values Kibana adds are dropped from an imported rule
+func normalizeRule(content []byte) (map[string]interface{}, error) {
+	var rule transferobjects.DetectionRule
+	if err := json.Unmarshal(content, &rule); err != nil {
+		return nil, err
+	}
+	normalizeRuleActions(&rule)
+	normalized, err := json.Marshal(rule)
+	if err != nil {
+		return nil, err
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(normalized, &result); err != nil {
+		return nil, err
+	}
+	if threshold, ok := result["threshold"].(map[string]interface{}); ok && len(threshold) == 0 {
+		delete(result, "threshold")
+	}
+	return result, nil
+}
+
+// importedRuleContent returns the rule content and the effective rule content of an imported rule. The
+// fields maintained by Kibana and the values added by the provider defaults are left out of the content,
+// so a configuration holding the same rule plans no change.
+func importedRuleContent(live []byte, defaults resourceDefaults) (string, string, error) {
+	content, err := normalizeRule(live)
+	if err != nil {
+		return "", "", err
+	}
+	for _, key := range ruleServerFields {
+		delete(content, key)
+	}
+
+	var defaultValues map[string]interface{}
+	if err := convertJSON(transferobjects.DetectionRule{
+		Author:     defaults.rule.author,
+		License:    defaults.rule.license,
+		Index:      defaults.rule.index,
+		Interval:   defaults.rule.interval,
+		From:       defaults.rule.from,
+		MaxSignals: defaults.rule.maxSignals,
+	}, &defaultValues); err != nil {
+		return "", "", err
+	}
+	for _, key := range []string{"author", "license", "index", "interval", "from", "max_signals"} {
+		if value, ok := defaultValues[key]; ok && reflect.DeepEqual(content[key], value) {
+			delete(content, key)
+		}
+	}
+	if tags, ok := content["tags"].([]interface{}); ok {
+		var contentTags []interface{}
+		for _, tag := range tags {
+			if tag, ok := tag.(string); !ok || !helpers.Contains(mergeTags(defaults.rule.tags, defaults.tags), tag) {
+				contentTags = append(contentTags, tag)
+			}
+		}
+		content["tags"] = contentTags
+		if len(contentTags) == 0 {
+			delete(content, "tags")
+		}
+	}
+
+	contentBytes, err := json.Marshal(content)
+	if err != nil {
+		return "", "", err
+	}
+	_, _, effectiveRuleContent, err := mergeRuleContent(string(contentBytes), defaults, nil)
+	if err != nil {
+		return "", "", err
+	}
+	return string(contentBytes), effectiveRuleContent, nil
+}
+
+func mergeKeys(objects ...map[string]interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	for _, object := range objects {
+		for key := range object {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+func effectiveTags(effectiveContent string) []interface{} {
+	var rule struct {
+		Tags []interface{} `json:"tags"`
+	}
+	json.Unmarshal([]byte(effectiveContent), &rule)
+	return rule.Tags
+}
+
+// withoutDefaultTags removes the tags the provider defaults added to the rule content from the live tags
+func withoutDefaultTags(liveTags interface{}, effectiveTags, contentTags []interface{}) interface{} {
+	tags, ok := liveTags.([]interface{})
+	if !ok {
+		return liveTags
+	}
+	result := []interface{}{}
+	for _, tag := range tags {
+		if helpers.Contains(effectiveTags, tag) && !helpers.Contains(contentTags, tag) {
+			continue
+		}
+		result = append(result, tag)
+	}
+	return result
+}
+
+// ImportState imports a rule by `<id>`, `rule_id:<rule_id>`, `<space_id>/<id>` or `<space_id>/rule_id:<rule_id>`.
+// The `rule_id` is the same in every environment a rule is deployed to, unlike the `id` Kibana generates.
 func (r *DetectionRuleResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	spaceID, id, qualified := strings.Cut(req.ID, "/")
+	if !qualified {
+		spaceID, id = "", req.ID
+	}
+	ruleID, byRuleID := strings.CutPrefix(id, "rule_id:")
+	if !byRuleID {
+		importStateWithSpace(ctx, req, resp)
+		return
+	}
+
+	if ruleID == "" || (qualified && spaceID == "") {
+		resp.Diagnostics.AddError("Invalid Import Identifier",
+			fmt.Sprintf("Expected an import identifier in the form `<id>`, `rule_id:<rule_id>`, `<space_id>/<id>` or `<space_id>/rule_id:<rule_id>`, got: %s", req.ID))
+		return
+	}
+
+	// Resolve the id of the rule, which is used by every other operation
+	var response transferobjects.DetectionRuleResponse
+	apiPath := fmt.Sprintf("/detection_engine/rules?rule_id=%s", url.QueryEscape(ruleID))
+	if err := r.client.WithSpace(spaceID).Get(ctx, apiPath, &response); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
+		return
+	}
+
+	if qualified {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("space_id"), spaceID)...)
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), response.ID)...)
 }
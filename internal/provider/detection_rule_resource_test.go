@@ -1,14 +1,19 @@
 package provider
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
+	"regexp"
 	"strconv"
+	"strings"
 	"terraform-provider-elastic-siem/internal/fakeserver"
 	"terraform-provider-elastic-siem/internal/provider/transferobjects"
 	"testing"
 
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
@@ -24,7 +29,12 @@ func generateTestRule() string {
 	ruleContent := transferobjects.DetectionRule{
 		RuleID:           "7CE764F6-36A7-4E72-AB8B-166170CD1C93",
 		ID:               "testID",
-		Type:             "detection",
+		Name:             "Test rule",
+		Description:      "Test rule",
+		Type:             "query",
+		Query:            "*:*",
+		RiskScore:        21,
+		Severity:         "low",
 		RiskScoreMapping: scoreMapping,
 	}
 	str, err := json.Marshal(ruleContent)
@@ -35,6 +45,20 @@ func generateTestRule() string {
 	return string(str)
 }
 
+// reformatTestRule indents the rule content and adds the Kibana default interval
+func reformatTestRule(t *testing.T, ruleContent string) string {
+	var content map[string]interface{}
+	if err := json.Unmarshal([]byte(ruleContent), &content); err != nil {
+		t.Fatal(err)
+	}
+	content["interval"] = "5m"
+	str, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(str)
+}
+
 func TestAccDetectionRuleResource(t *testing.T) {
 
 	debug := true
@@ -100,6 +124,17 @@ func TestAccDetectionRuleResource(t *testing.T) {
 					),
 				),
 			},
+			// Rule content Kibana would reject is reported by the plan
+			{
+				Config:      testAccDetectionRuleResourceConfig(`{"name":"Test rule","description":"Test rule","risk_score":21,"severity":"low","type":"eql","language":"kuery","query":"any where true"}`, "test"),
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("`language`: `eql` rules support the languages eql"),
+			},
+			// Reformatted content spelling out Kibana defaults is not planned as an update
+			{
+				Config:   testAccDetectionRuleResourceConfig(reformatTestRule(t, generateTestRule()), "test"),
+				PlanOnly: true,
+			},
 			// ImportState testing
 			{
 				ResourceName:      "elastic-siem_detection_rule.test",
@@ -109,7 +144,44 @@ func TestAccDetectionRuleResource(t *testing.T) {
 				// example code does not have an actual upstream service.
 				// Once the Read method is able to refresh information from
 				// the upstream service, this can be removed.
-				ImportStateVerifyIgnore: []string{"rule_content", "exception_type"},
+				ImportStateVerifyIgnore: []string{"rule_content", "effective_rule_content", "exception_type"},
+			},
+			// Import by rule_id, the rule content is read from Kibana
+			{
+				ResourceName:  "elastic-siem_detection_rule.test",
+				ImportState:   true,
+				ImportStateId: "rule_id:7CE764F6-36A7-4E72-AB8B-166170CD1C93",
+				ImportStateCheck: func(states []*terraform.InstanceState) error {
+					if len(states) != 1 || states[0].ID != "testID" {
+						return fmt.Errorf("expected the rule testID to be imported, got %v", states)
+					}
+					content := states[0].Attributes["rule_content"]
+					if !strings.Contains(content, `"name":"Test rule"`) || strings.Contains(content, `"id"`) {
+						return fmt.Errorf("expected the rule content without server fields, got %s", content)
+					}
+					if states[0].Attributes["exception_type"] != "detection" {
+						return fmt.Errorf("expected the default exception type, got %s", states[0].Attributes["exception_type"])
+					}
+					return nil
+				},
+			},
+			// A rule changed in Kibana is planned to be restored
+			{
+				PreConfig: func() {
+					apiServerObjects["rules"]["severity"] = "high"
+				},
+				Config:             testAccDetectionRuleResourceConfig(generateTestRule(), "test"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			// A rule deleted in Kibana is planned to be created again
+			{
+				PreConfig: func() {
+					delete(apiServerObjects, "rules")
+				},
+				Config:             testAccDetectionRuleResourceConfig(generateTestRule(), "test"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
 			},
 			// Update and Read testing
 			{
@@ -119,6 +191,29 @@ func TestAccDetectionRuleResource(t *testing.T) {
 					resource.TestCheckResourceAttr("elastic-siem_detection_rule.test", "exception_type", "detection"),
 				),
 			},
+			// Exception containers of the blocks are merged after those of the rule content
+			{
+				Config: testAccDetectionRuleResourceExceptionListConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("elastic-siem_detection_rule.test", "exception_list.#", "2"),
+					resource.TestCheckResourceAttr("elastic-siem_detection_rule.test", "exception_list.0.namespace_type", "single"),
+					func(s *terraform.State) error {
+						var lists []transferobjects.ExceptionListItem
+						if err := convertJSON(apiServerObjects["rules"]["exceptions_list"], &lists); err != nil {
+							return err
+						}
+						var listIds []string
+						for _, list := range lists {
+							listIds = append(listIds, list.NamespaceType+"/"+list.ListID+"/"+list.Type)
+						}
+						expected := "single/shared-list/detection,single/rule-default-list/rule_default,agnostic/endpoint_list/endpoint"
+						if strings.Join(listIds, ",") != expected {
+							return fmt.Errorf("expected the exception lists %s, got %v", expected, listIds)
+						}
+						return nil
+					},
+				),
+			},
 			// Delete testing automatically occurs in TestCase
 		},
 	})
@@ -126,6 +221,142 @@ func TestAccDetectionRuleResource(t *testing.T) {
 	svr.Shutdown()
 }
 
+func TestAccDetectionRuleResourceExceptionListLookup(t *testing.T) {
+	apiServerObjects := make(map[string]map[string]interface{})
+	svr := fakeserver.NewFakeServer(test_post, apiServerObjects, true, false, "")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			svr.StartInBackground()
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// The ID of a container created in the same apply is looked up by its list ID
+			{
+				Config: testAccDetectionRuleResourceExceptionListLookupConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("elastic-siem_detection_rule.test", "exception_list.0.id", "generatedTestID"),
+					testAccCheckRuleExceptionListId(apiServerObjects, "generatedTestID"),
+				),
+			},
+			// A container recreated with the same list ID is linked again
+			{
+				PreConfig: func() {
+					apiServerObjects["generatedTestID"]["id"] = "recreatedTestID"
+				},
+				Config: testAccDetectionRuleResourceExceptionListLookupConfig(),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("elastic-siem_detection_rule.test", "exception_list.0.id", "recreatedTestID"),
+					testAccCheckRuleExceptionListId(apiServerObjects, "recreatedTestID"),
+				),
+			},
+		},
+	})
+
+	svr.Shutdown()
+}
+
+func TestAccDetectionRuleResourceEnabled(t *testing.T) {
+	apiServerObjects := make(map[string]map[string]interface{})
+	svr := fakeserver.NewFakeServer(test_post, apiServerObjects, true, false, "")
+	var puts, bulkActions int
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			svr.StartInBackground()
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: testAccDetectionRuleResourceEnabledConfig(true),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("elastic-siem_detection_rule.test", "enabled", "true"),
+					testAccCheckRuleEnabled(apiServerObjects, true),
+				),
+			},
+			// Disabling the rule sends a bulk action, the rule is not updated
+			{
+				PreConfig: func() {
+					puts = svr.RequestCount("PUT", "/api/detection_engine/rules")
+					bulkActions = svr.RequestCount("POST", "/api/detection_engine/rules/_bulk_action")
+				},
+				Config: testAccDetectionRuleResourceEnabledConfig(false),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("elastic-siem_detection_rule.test", "enabled", "false"),
+					resource.TestCheckResourceAttr("elastic-siem_detection_rule.test", "rule_content", generateTestRule()),
+					resource.TestMatchResourceAttr("elastic-siem_detection_rule.test", "effective_rule_content", regexp.MustCompile(`"enabled":false`)),
+					testAccCheckRuleEnabled(apiServerObjects, false),
+					func(s *terraform.State) error {
+						if count := svr.RequestCount("PUT", "/api/detection_engine/rules") - puts; count != 0 {
+							return fmt.Errorf("expected the rule not to be updated, got %d updates", count)
+						}
+						if count := svr.RequestCount("POST", "/api/detection_engine/rules/_bulk_action") - bulkActions; count != 1 {
+							return fmt.Errorf("expected a single bulk action, got %d", count)
+						}
+						return nil
+					},
+				),
+			},
+			// A rule enabled in Kibana is planned to be disabled again
+			{
+				PreConfig: func() {
+					apiServerObjects["rules"]["enabled"] = true
+				},
+				Config:             testAccDetectionRuleResourceEnabledConfig(false),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+		},
+	})
+
+	svr.Shutdown()
+}
+
+func testAccDetectionRuleResourceEnabledConfig(enabled bool) string {
+	return fmt.Sprintf(`%s
+resource "elastic-siem_detection_rule" "test" {
+  enabled      = %t
+  rule_content = %s
+}
+`, providerConfig, enabled, strconv.Quote(generateTestRule()))
+}
+
+func testAccCheckRuleEnabled(apiServerObjects map[string]map[string]interface{}, enabled bool) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		if apiServerObjects["rules"]["enabled"] != enabled {
+			return fmt.Errorf("expected the rule to have enabled %t, got %v", enabled, apiServerObjects["rules"]["enabled"])
+		}
+		return nil
+	}
+}
+
+func testAccDetectionRuleResourceExceptionListLookupConfig() string {
+	return fmt.Sprintf(`%s
+resource "elastic-siem_detection_rule" "test" {
+  rule_content = %s
+
+  exception_list {
+    list_id = elastic-siem_exception_container.test.list_id
+  }
+}
+`, testAccExceptionContainerResourceConfig(generateTestExceptionContainer(), "test"), strconv.Quote(generateTestRule()))
+}
+
+func testAccCheckRuleExceptionListId(apiServerObjects map[string]map[string]interface{}, id string) resource.TestCheckFunc {
+	return func(s *terraform.State) error {
+		var lists []transferobjects.ExceptionListItem
+		if err := convertJSON(apiServerObjects["rules"]["exceptions_list"], &lists); err != nil {
+			return err
+		}
+		if len(lists) != 1 || lists[0].ID != id || lists[0].ListID != generateTestExceptionContainer().ListID {
+			return fmt.Errorf("expected the rule to reference the container %s, got %v", id, lists)
+		}
+		return nil
+	}
+}
+
 func testAccDetectionRuleResourceConfig(ruleContent string, name string) string {
 	content := strconv.Quote(string(ruleContent))
 	return fmt.Sprintf(`%s
@@ -135,3 +366,145 @@ resource "elastic-siem_detection_rule" "%s" {
 }
 `, providerConfig, name, content)
 }
+
+func testAccDetectionRuleResourceExceptionListConfig() string {
+	var content map[string]interface{}
+	json.Unmarshal([]byte(generateTestRule()), &content)
+	content["exceptions_list"] = []map[string]string{
+		{"id": "shared-id", "list_id": "shared-list", "type": "detection", "namespace_type": "single"},
+	}
+	ruleContent, _ := json.Marshal(content)
+	return fmt.Sprintf(`%s
+resource "elastic-siem_detection_rule" "test" {
+  rule_content = %s
+
+  exception_list {
+    id      = "rule-default-id"
+    list_id = "rule-default-list"
+    type    = "rule_default"
+  }
+
+  exception_list {
+    id             = "endpoint_list"
+    list_id        = "endpoint_list"
+    type           = "endpoint"
+    namespace_type = "agnostic"
+  }
+}
+`, providerConfig, strconv.Quote(string(ruleContent)))
+}
+
+func TestDetectRuleDrift(t *testing.T) {
+	data := &DetectionRuleResourceModel{
+		RuleContent:          ruleContentType.NewValue(`{"name":"My rule","type":"query","tags":["Linux"],"risk_score":21}`),
+		EffectiveRuleContent: types.StringValue(`{"interval":"5m","name":"My rule","risk_score":21,"tags":["Linux","managed-by: terraform"],"type":"query"}`),
+		ExceptionContainerId: types.StringNull(),
+	}
+
+	live := `{"id":"1","name":"My rule","type":"query","tags":["Linux","managed-by: terraform"],"risk_score":21,"interval":"5m",` +
+		`"version":3,"max_signals":100,"enabled":true,"created_at":"2024-01-01T00:00:00Z","threshold":{}}`
+	if err := detectRuleDrift(context.Background(), data, []byte(live)); err != nil {
+		t.Fatal(err)
+	}
+	if data.RuleContent.ValueString() != `{"name":"My rule","type":"query","tags":["Linux"],"risk_score":21}` {
+		t.Errorf("expected server fields and defaults not to be reported as drift, got %s", data.RuleContent.ValueString())
+	}
+
+	live = `{"id":"1","name":"My rule","type":"query","tags":["Linux","managed-by: terraform","Edited"],"risk_score":73,"interval":"1h"}`
+	if err := detectRuleDrift(context.Background(), data, []byte(live)); err != nil {
+		t.Fatal(err)
+	}
+	if data.RuleContent.ValueString() != `{"name":"My rule","risk_score":73,"tags":["Linux","Edited"],"type":"query"}` {
+		t.Errorf("unexpected rule content: %s", data.RuleContent.ValueString())
+	}
+	if data.EffectiveRuleContent.ValueString() != `{"interval":"1h","name":"My rule","risk_score":73,"tags":["Linux","managed-by: terraform","Edited"],"type":"query"}` {
+		t.Errorf("unexpected effective rule content: %s", data.EffectiveRuleContent.ValueString())
+	}
+
+	// The managed fields are reported although the rule content does not set them, the generated rule_id,
+	// empty strings and keys set by the detection engine are not
+	live = `{"id":"1","rule_id":"5c8e2a1b","name":"My rule","type":"query","tags":["Linux","managed-by: terraform","Edited"],` +
+		`"risk_score":73,"interval":"1h","license":"","timeline_id":"","language":"kuery","rule_source":{"type":"internal"},` +
+		`"related_integrations":[],"required_fields":[{"name":"host.name","type":"keyword"}],"note":"Written in Kibana",` +
+		`"investigation_fields":{"field_names":["host.name"]}}`
+	if err := detectRuleDrift(context.Background(), data, []byte(live)); err != nil {
+		t.Fatal(err)
+	}
+	if data.RuleContent.ValueString() != `{"investigation_fields":{"field_names":["host.name"]},"name":"My rule","note":"Written in Kibana","risk_score":73,"tags":["Linux","Edited"],"type":"query"}` {
+		t.Errorf("expected the keys added in Kibana to be written to the rule content, got %s", data.RuleContent.ValueString())
+	}
+	body, _, _, err := mergeRuleContent(data.RuleContent.ValueString(), resourceDefaults{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Note != "Written in Kibana" || body.InvestigationFields == nil || !reflect.DeepEqual(body.InvestigationFields.FieldNames, []string{"host.name"}) {
+		t.Errorf("expected the keys written to the rule content to be sent, got %+v", body)
+	}
+	if err := detectRuleDrift(context.Background(), data, []byte(live)); err != nil {
+		t.Fatal(err)
+	}
+	if data.RuleContent.ValueString() != `{"investigation_fields":{"field_names":["host.name"]},"name":"My rule","note":"Written in Kibana","risk_score":73,"tags":["Linux","Edited"],"type":"query"}` {
+		t.Errorf("expected the rule content to converge, got %s", data.RuleContent.ValueString())
+	}
+}
+
+func TestImportedRuleContent(t *testing.T) {
+	defaults := resourceDefaults{
+		rule: ruleDefaults{interval: "1h", tags: []string{"managed-by: terraform"}},
+		tags: []string{"team: soc"},
+	}
+	live := `{"id":"abc","rule_id":"my-rule","revision":4,"name":"My rule","type":"query","risk_score":21,` +
+		`"interval":"1h","tags":["Linux","managed-by: terraform","team: soc"],"enabled":true,"max_signals":100,"actions":[]}`
+
+	content, effective, err := importedRuleContent([]byte(live), defaults)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != `{"enabled":true,"max_signals":100,"name":"My rule","risk_score":21,"rule_id":"my-rule","tags":["Linux"],"type":"query"}` {
+		t.Errorf("unexpected rule content: %s", content)
+	}
+	if effective != `{"enabled":true,"interval":"1h","max_signals":100,"name":"My rule","risk_score":21,"rule_id":"my-rule","tags":["Linux","managed-by: terraform","team: soc"],"type":"query"}` {
+		t.Errorf("unexpected effective rule content: %s", effective)
+	}
+
+	// The configuration of the rule plans no change against the imported content
+	config := ruleContentType.NewValue(`{"rule_id":"my-rule","name":"My rule","type":"query","risk_score":21,"tags":["Linux"]}`)
+	if equal, _ := ruleContentType.NewValue(content).StringSemanticEquals(context.Background(), config); !equal {
+		t.Errorf("expected the imported content to equal the configuration")
+	}
+}
+
+func TestMergeDetectionRuleContentEnabled(t *testing.T) {
+	data := &DetectionRuleResourceModel{
+		RuleContent: ruleContentType.NewValue(`{"name":"My rule","type":"query","enabled":true}`),
+		Enabled:     types.BoolValue(false),
+	}
+	body, _, effective, err := mergeDetectionRuleContent(data, resourceDefaults{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body.Enabled == nil || *body.Enabled {
+		t.Errorf("expected the enabled attribute to override the rule content, got %v", body.Enabled)
+	}
+	if !strings.Contains(effective, `"enabled":false`) {
+		t.Errorf("expected the effective content to hold the enabled state sent, got %s", effective)
+	}
+
+	data.Enabled = types.BoolNull()
+	_, _, contentEffective, err := mergeDetectionRuleContent(data, resourceDefaults{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(contentEffective, `"enabled":true`) {
+		t.Errorf("expected the enabled state of the rule content, got %s", contentEffective)
+	}
+	if !equalRuleContent(effective, contentEffective, true) || equalRuleContent(effective, contentEffective, false) {
+		t.Errorf("expected %s and %s to only differ in their enabled state", effective, contentEffective)
+	}
+	if equalRuleContent(effective, `{"name":"Other rule","type":"query","enabled":false}`, true) {
+		t.Error("expected a different rule content not to be equal")
+	}
+	if !equalRuleContent(`{"type":"query","name":"My rule"}`, `{ "name": "My rule", "type": "query", "interval": "5m" }`, false) {
+		t.Error("expected contents only differing in key order, formatting and defaults to be equal")
+	}
+}
@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"terraform-provider-elastic-siem/internal/helpers"
+)
+
+// addClientErrorDiagnostic turns an error returned by the client into a diagnostic. Fields rejected
+// by Kibana are reported on contentPath, the attribute holding the request body (if there is one).
+func addClientErrorDiagnostic(diags *diag.Diagnostics, err error, contentPath path.Path) {
+	var apiError *helpers.APIError
+	if !errors.As(err, &apiError) {
+		diags.AddError("Client Error", fmt.Sprintf("Error during request, got error: %s", err))
+		return
+	}
+
+	summary := "Client Error"
+	switch {
+	case helpers.IsNotFound(err):
+		summary = "Object Not Found"
+	case helpers.IsConflict(err):
+		summary = "Conflicting Object"
+	case helpers.IsValidation(err):
+		summary = "Invalid Request"
+	case helpers.IsUnauthorized(err):
+		summary = "Authentication Failed"
+	case helpers.IsForbidden(err):
+		summary = "Missing Privileges"
+	}
+
+	if len(apiError.FieldErrors) == 0 {
+		diags.AddError(summary, apiError.Error())
+		return
+	}
+	for _, fieldError := range apiError.FieldErrors {
+		detail := fmt.Sprintf("Kibana rejected the field `%s`: %s\n\n%s", fieldError.Path, fieldError.Message, apiError.Error())
+		if len(contentPath.Steps()) == 0 {
+			diags.AddError(summary, detail)
+		} else {
+			diags.AddAttributeError(contentPath, summary, detail)
+		}
+	}
+}
+
+// removeMissingResource removes an object deleted outside of Terraform from the state, so the next plan
+// creates it again. It reports whether the error was a not found error and has been handled.
+func removeMissingResource(ctx context.Context, err error, resp *resource.ReadResponse) bool {
+	if !helpers.IsNotFound(err) {
+		return false
+	}
+	tflog.Warn(ctx, "Object not found in Kibana, removing it from the state", map[string]interface{}{
+		"error": err.Error(),
+	})
+	resp.State.RemoveResource(ctx)
+	return true
+}
+
+// deleteObject deletes an object through the API, an object already deleted outside of Terraform is not an error
+func deleteObject(ctx context.Context, client *helpers.Client, apiPath string, diags *diag.Diagnostics) {
+	if err := client.Delete(ctx, apiPath); err != nil && !helpers.IsNotFound(err) {
+		addClientErrorDiagnostic(diags, err, path.Empty())
+	}
+}
@@ -3,7 +3,9 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -11,7 +13,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"strconv"
 	"terraform-provider-elastic-siem/internal/helpers"
 	"terraform-provider-elastic-siem/internal/provider/transferobjects"
 )
@@ -19,6 +20,7 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces
 var _ resource.Resource = &ExceptionContainerResource{}
 var _ resource.ResourceWithImportState = &ExceptionContainerResource{}
+var _ resource.ResourceWithModifyPlan = &ExceptionContainerResource{}
 
 func NewExceptionContainerResource() resource.Resource {
 	return &ExceptionContainerResource{}
@@ -26,18 +28,22 @@ func NewExceptionContainerResource() resource.Resource {
 
 // ExceptionContainerResource defines the resource implementation.
 type ExceptionContainerResource struct {
-	client *helpers.Client
+	client   *helpers.Client
+	defaults resourceDefaults
 }
 
 // ExceptionContainerResourceModel describes the resource data model.
 type ExceptionContainerResourceModel struct {
-	Description   types.String `tfsdk:"description"`
-	Name          types.String `tfsdk:"name"`
-	ListId        types.String `tfsdk:"list_id"`
-	Type          types.String `tfsdk:"type"`
-	NamespaceType types.String `tfsdk:"namespace_type"`
-	Tags          types.List   `tfsdk:"tags"`
-	Id            types.String `tfsdk:"id"`
+	Description   types.String   `tfsdk:"description"`
+	Name          types.String   `tfsdk:"name"`
+	ListId        types.String   `tfsdk:"list_id"`
+	Type          types.String   `tfsdk:"type"`
+	NamespaceType types.String   `tfsdk:"namespace_type"`
+	Tags          types.List     `tfsdk:"tags"`
+	TagsAll       types.List     `tfsdk:"tags_all"`
+	SpaceId       types.String   `tfsdk:"space_id"`
+	Id            types.String   `tfsdk:"id"`
+	Timeouts      timeouts.Value `tfsdk:"timeouts"`
 }
 
 func (r *ExceptionContainerResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -77,6 +83,12 @@ func (r *ExceptionContainerResource) Schema(ctx context.Context, req resource.Sc
 				ElementType:         types.StringType,
 				Optional:            true,
 			},
+			"tags_all": schema.ListAttribute{
+				MarkdownDescription: "The tags of the exception container merged with the provider `default_tags`",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"space_id": spaceIdAttribute(),
 			"id": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Exception container identifier (in UUID format)",
@@ -85,6 +97,14 @@ func (r *ExceptionContainerResource) Schema(ctx context.Context, req resource.Sc
 				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -94,18 +114,46 @@ func (r *ExceptionContainerResource) Configure(ctx context.Context, req resource
 		return
 	}
 
-	client, ok := req.ProviderData.(*helpers.Client)
+	data, ok := req.ProviderData.(*providerData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = data.client
+	r.defaults = data.defaults
+}
+
+func (r *ExceptionContainerResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to merge on destroy or before the provider is configured
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	var data *ExceptionContainerResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() || data.Tags.IsUnknown() {
+		return
+	}
+
+	tagsAll, diags := r.tagsAll(ctx, data)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("tags_all"), tagsAll)...)
+}
+
+// tagsAll merges the tags of the exception container with the provider default tags
+func (r *ExceptionContainerResource) tagsAll(ctx context.Context, data *ExceptionContainerResourceModel) (types.List, diag.Diagnostics) {
+	var tags []string
+	diags := data.Tags.ElementsAs(ctx, &tags, false)
+	tagsAll, listDiags := types.ListValueFrom(ctx, types.StringType, r.defaults.applyToTags(tags))
+	diags.Append(listDiags...)
+	return tagsAll, diags
 }
 
 func (r *ExceptionContainerResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -119,6 +167,13 @@ func (r *ExceptionContainerResource) Create(ctx context.Context, req resource.Cr
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	body = &transferobjects.ExceptionContainer{
 		ListID:        data.ListId.ValueString(),
 		Name:          data.Name.ValueString(),
@@ -127,18 +182,19 @@ func (r *ExceptionContainerResource) Create(ctx context.Context, req resource.Cr
 		Type:          data.Type.ValueString(),
 		Tags:          []string{},
 	}
-	for _, element := range data.Tags.Elements() {
-		s, err := strconv.Unquote(element.String())
-		if err != nil {
-			body.Tags = append(body.Tags, element.String())
-		}
-		body.Tags = append(body.Tags, s)
+	tagsAll, diags := r.tagsAll(ctx, data)
+	data.TagsAll = tagsAll
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(data.TagsAll.ElementsAs(ctx, &body.Tags, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Create the rule through API
 	var response transferobjects.ExceptionContainerResponse
-	if err := r.client.Post("/exception_lists", body, &response, []string{}); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: \n%s", err))
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Post(ctx, "/exception_lists", body, &response, []string{}); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
 		return
 	}
 
@@ -159,11 +215,21 @@ func (r *ExceptionContainerResource) Read(ctx context.Context, req resource.Read
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Get the rule through the API
 	var response transferobjects.ExceptionContainerResponse
 	apiPath := fmt.Sprintf("/exception_lists?id=%s", data.Id.ValueString())
-	if err := r.client.Get(apiPath, &response); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: %s", err))
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Get(ctx, apiPath, &response); err != nil {
+		if removeMissingResource(ctx, err, resp) {
+			return
+		}
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
 		return
 	}
 
@@ -182,6 +248,13 @@ func (r *ExceptionContainerResource) Update(ctx context.Context, req resource.Up
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	body = &transferobjects.ExceptionContainer{
 		ListID:        data.ListId.ValueString(),
 		Name:          data.Name.ValueString(),
@@ -191,18 +264,19 @@ func (r *ExceptionContainerResource) Update(ctx context.Context, req resource.Up
 		Tags:          []string{},
 		ID:            data.Id.ValueString(),
 	}
-	for _, element := range data.Tags.Elements() {
-		s, err := strconv.Unquote(element.String())
-		if err != nil {
-			body.Tags = append(body.Tags, element.String())
-		}
-		body.Tags = append(body.Tags, s)
+	tagsAll, diags := r.tagsAll(ctx, data)
+	data.TagsAll = tagsAll
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(data.TagsAll.ElementsAs(ctx, &body.Tags, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Create the rule through API
 	var response transferobjects.ExceptionContainerResponse
-	if err := r.client.Put("/exception_lists", body, &response, []string{}); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: \n%s", err))
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Put(ctx, "/exception_lists", body, &response, []string{}); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
 		return
 	}
 
@@ -220,14 +294,18 @@ func (r *ExceptionContainerResource) Delete(ctx context.Context, req resource.De
 		return
 	}
 
-	// Get the rule through the API
-	apiPath := fmt.Sprintf("/exception_lists?id=%s", data.Id.ValueString())
-	if err := r.client.Delete(apiPath); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: %s", err))
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	// Get the rule through the API
+	apiPath := fmt.Sprintf("/exception_lists?id=%s", data.Id.ValueString())
+	deleteObject(ctx, r.client.WithSpace(data.SpaceId.ValueString()), apiPath, &resp.Diagnostics)
 }
 
 func (r *ExceptionContainerResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	importStateWithSpace(ctx, req, resp)
 }
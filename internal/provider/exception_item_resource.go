@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
@@ -16,6 +17,7 @@ import (
 // Ensure provider defined types fully satisfy framework interfaces
 var _ resource.Resource = &ExceptionItemResource{}
 var _ resource.ResourceWithImportState = &ExceptionItemResource{}
+var _ resource.ResourceWithModifyPlan = &ExceptionItemResource{}
 
 func NewExceptionItemResource() resource.Resource {
 	return &ExceptionItemResource{}
@@ -23,14 +25,18 @@ func NewExceptionItemResource() resource.Resource {
 
 // ExceptionItemResource defines the resource implementation.
 type ExceptionItemResource struct {
-	client *helpers.Client
+	client   *helpers.Client
+	defaults resourceDefaults
 }
 
 // ExceptionItemResourceModel describes the resource data model.
 type ExceptionItemResourceModel struct {
-	ExceptionContent types.String `tfsdk:"exception_item_content"`
-	ListIdOverride   types.String `tfsdk:"list_id_override"`
-	Id               types.String `tfsdk:"id"`
+	ExceptionContent JSONContentValue `tfsdk:"exception_item_content"`
+	ListIdOverride   types.String     `tfsdk:"list_id_override"`
+	TagsAll          types.List       `tfsdk:"tags_all"`
+	SpaceId          types.String     `tfsdk:"space_id"`
+	Id               types.String     `tfsdk:"id"`
+	Timeouts         timeouts.Value   `tfsdk:"timeouts"`
 }
 
 func (r *ExceptionItemResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -44,13 +50,23 @@ func (r *ExceptionItemResource) Schema(ctx context.Context, req resource.SchemaR
 
 		Attributes: map[string]schema.Attribute{
 			"exception_item_content": schema.StringAttribute{
-				MarkdownDescription: "The content of the exception item (JSON encoded string)",
+				MarkdownDescription: "The content of the exception item (JSON encoded string). Formatting, key order and values equal to the Kibana defaults are not considered a change.",
+				CustomType:          exceptionItemContentType,
 				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					suppressEquivalentJSONContent(),
+				},
 			},
 			"list_id_override": schema.StringAttribute{
 				MarkdownDescription: "The list ID that should be used for the item (overrides id in exception_item_content)",
 				Optional:            true,
 			},
+			"tags_all": schema.ListAttribute{
+				MarkdownDescription: "The tags of the exception item merged with the provider `default_tags`",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"space_id": spaceIdAttribute(),
 			"id": schema.StringAttribute{
 				Computed:            true,
 				MarkdownDescription: "Exception item identifier (in UUID format)",
@@ -59,6 +75,14 @@ func (r *ExceptionItemResource) Schema(ctx context.Context, req resource.SchemaR
 				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -68,18 +92,42 @@ func (r *ExceptionItemResource) Configure(ctx context.Context, req resource.Conf
 		return
 	}
 
-	client, ok := req.ProviderData.(*helpers.Client)
+	data, ok := req.ProviderData.(*providerData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = data.client
+	r.defaults = data.defaults
+}
+
+func (r *ExceptionItemResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to merge on destroy or before the provider is configured
+	if req.Plan.Raw.IsNull() || r.client == nil {
+		return
+	}
+
+	var data *ExceptionItemResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() || data.ExceptionContent.IsUnknown() {
+		return
+	}
+
+	var body transferobjects.ExceptionItem
+	if err := helpers.ObjectFronJSON(data.ExceptionContent.ValueString(), &body); err != nil {
+		// Parser errors are reported during apply
+		return
+	}
+	tagsAll, diags := types.ListValueFrom(ctx, types.StringType, r.defaults.applyToTags(body.Tags))
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("tags_all"), tagsAll)...)
 }
 
 func (r *ExceptionItemResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -93,6 +141,13 @@ func (r *ExceptionItemResource) Create(ctx context.Context, req resource.CreateR
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Process the rule content
 	err := helpers.ObjectFronJSON(data.ExceptionContent.ValueString(), &body)
 	if err != nil {
@@ -104,10 +159,19 @@ func (r *ExceptionItemResource) Create(ctx context.Context, req resource.CreateR
 		body.ListID = data.ListIdOverride.ValueString()
 	}
 
+	body.Tags = r.defaults.applyToTags(body.Tags)
+	tagsAll, diags := types.ListValueFrom(ctx, types.StringType, body.Tags)
+	data.TagsAll = tagsAll
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Create the rule through API
 	var response transferobjects.ExceptionItemResponse
-	if err := r.client.Post("/exception_lists/items", body, &response, []string{}); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: \n%s", err))
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Post(ctx, "/exception_lists/items", body, &response, []string{}); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Root("exception_item_content"))
 		return
 	}
 
@@ -128,11 +192,21 @@ func (r *ExceptionItemResource) Read(ctx context.Context, req resource.ReadReque
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Get the rule through the API
 	var response transferobjects.ExceptionItemResponse
-	path := fmt.Sprintf("/exception_lists/items?id=%s", data.Id.ValueString())
-	if err := r.client.Get(path, &response); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: %s", err))
+	apiPath := fmt.Sprintf("/exception_lists/items?id=%s", data.Id.ValueString())
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Get(ctx, apiPath, &response); err != nil {
+		if removeMissingResource(ctx, err, resp) {
+			return
+		}
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
 		return
 	}
 
@@ -151,6 +225,13 @@ func (r *ExceptionItemResource) Update(ctx context.Context, req resource.UpdateR
 		return
 	}
 
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Process the rule content
 	err := helpers.ObjectFronJSON(data.ExceptionContent.ValueString(), &body)
 	if err != nil {
@@ -160,10 +241,19 @@ func (r *ExceptionItemResource) Update(ctx context.Context, req resource.UpdateR
 
 	body.ID = data.Id.ValueString()
 
+	body.Tags = r.defaults.applyToTags(body.Tags)
+	tagsAll, diags := types.ListValueFrom(ctx, types.StringType, body.Tags)
+	data.TagsAll = tagsAll
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
 	// Create the rule through API
 	var response transferobjects.ExceptionItemResponse
-	if err := r.client.Put("/exception_lists/items", body, &response, []string{}); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: \n%s", err))
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Put(ctx, "/exception_lists/items", body, &response, []string{}); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Root("exception_item_content"))
 		return
 	}
 
@@ -181,14 +271,18 @@ func (r *ExceptionItemResource) Delete(ctx context.Context, req resource.DeleteR
 		return
 	}
 
-	// Get the rule through the API
-	path := fmt.Sprintf("/exception_lists/items?id=%s", data.Id.ValueString())
-	if err := r.client.Delete(path); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: %s", err))
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	// Get the rule through the API
+	apiPath := fmt.Sprintf("/exception_lists/items?id=%s", data.Id.ValueString())
+	deleteObject(ctx, r.client.WithSpace(data.SpaceId.ValueString()), apiPath, &resp.Diagnostics)
 }
 
 func (r *ExceptionItemResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	importStateWithSpace(ctx, req, resp)
 }
@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
 )
 
 func generateTestExceptionItem() string {
@@ -84,6 +85,16 @@ func TestAccExceptionItemResource(t *testing.T) {
 				// the upstream service, this can be removed.
 				ImportStateVerifyIgnore: []string{"exception_item_content"},
 			},
+			// ImportState testing with a space qualified identifier
+			{
+				ResourceName:      "elastic-siem_exception_item.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				ImportStateIdFunc: func(s *terraform.State) (string, error) {
+					return "default/" + s.RootModule().Resources["elastic-siem_exception_item.test"].Primary.ID, nil
+				},
+				ImportStateVerifyIgnore: []string{"exception_item_content", "space_id"},
+			},
 			// Update and Read testing
 			{
 				Config: testAccExceptionItemResourceConfig(generateTestExceptionItem(), "test"),
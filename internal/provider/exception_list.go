@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"net/url"
+	"terraform-provider-elastic-siem/internal/helpers"
+	"terraform-provider-elastic-siem/internal/provider/transferobjects"
+)
+
+// ExceptionListModel describes an exception container attached to a rule
+type ExceptionListModel struct {
+	Id            types.String `tfsdk:"id"`
+	ListId        types.String `tfsdk:"list_id"`
+	Type          types.String `tfsdk:"type"`
+	NamespaceType types.String `tfsdk:"namespace_type"`
+}
+
+// exceptionListBlock returns the schema of the blocks attaching exception containers to a rule
+func exceptionListBlock() schema.ListNestedBlock {
+	return schema.ListNestedBlock{
+		MarkdownDescription: "An exception container applied to the rule",
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"id": schema.StringAttribute{
+					MarkdownDescription: "The ID of the exception container, looked up by `list_id` and `namespace_type` when not set",
+					Optional:            true,
+					Computed:            true,
+				},
+				"list_id": schema.StringAttribute{
+					MarkdownDescription: "The list ID of the exception container",
+					Required:            true,
+				},
+				"type": schema.StringAttribute{
+					MarkdownDescription: "The type of the exception container, one of `detection`, `endpoint` or `rule_default` (defaults to `detection`)",
+					Optional:            true,
+					Computed:            true,
+					Default:             stringdefault.StaticString("detection"),
+					Validators:          []validator.String{stringvalidator.OneOf("detection", "endpoint", "rule_default")},
+				},
+				"namespace_type": schema.StringAttribute{
+					MarkdownDescription: "Whether the container belongs to the space (`single`) or to all spaces (`agnostic`), defaults to `single`",
+					Optional:            true,
+					Computed:            true,
+					Default:             stringdefault.StaticString("single"),
+					Validators:          []validator.String{stringvalidator.OneOf("single", "agnostic")},
+				},
+			},
+		},
+	}
+}
+
+// exceptionListItems converts the blocks into the transfer objects. It reports whether all values are
+// known, the ID of a container created in the same apply is only known then.
+func exceptionListItems(lists []ExceptionListModel) ([]transferobjects.ExceptionListItem, bool) {
+	var items []transferobjects.ExceptionListItem
+	known := true
+	for _, list := range lists {
+		if list.Id.IsUnknown() || list.ListId.IsUnknown() || list.Type.IsUnknown() || list.NamespaceType.IsUnknown() {
+			known = false
+		}
+		items = append(items, transferobjects.ExceptionListItem{
+			ID:            list.Id.ValueString(),
+			ListID:        list.ListId.ValueString(),
+			Type:          list.Type.ValueString(),
+			NamespaceType: list.NamespaceType.ValueString(),
+		})
+	}
+	return items, known
+}
+
+// exceptionListKey identifies an exception container, its list ID is unique within its namespace
+func exceptionListKey(item transferobjects.ExceptionListItem) string {
+	namespaceType := item.NamespaceType
+	if namespaceType == "" {
+		namespaceType = "single"
+	}
+	return namespaceType + "/" + item.ListID
+}
+
+// mergeExceptionLists concatenates the exception lists in order. A container present in several lists is
+// kept at its first position, with the values of its last occurrence.
+func mergeExceptionLists(lists ...[]transferobjects.ExceptionListItem) []transferobjects.ExceptionListItem {
+	var result []transferobjects.ExceptionListItem
+	positions := make(map[string]int)
+	for _, list := range lists {
+		for _, item := range list {
+			key := exceptionListKey(item)
+			if position, ok := positions[key]; ok {
+				result[position] = item
+				continue
+			}
+			positions[key] = len(result)
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// withoutAttachedExceptionLists removes the containers attached by the resource from the live exception
+// list of a rule, unless the rule content lists them as well
+func withoutAttachedExceptionLists(liveLists interface{}, attached []transferobjects.ExceptionListItem, contentLists interface{}) interface{} {
+	var live, content []transferobjects.ExceptionListItem
+	if err := convertJSON(liveLists, &live); err != nil {
+		return liveLists
+	}
+	convertJSON(contentLists, &content)
+
+	skipped := make(map[string]bool)
+	for _, item := range attached {
+		skipped[exceptionListKey(item)] = true
+	}
+	for _, item := range content {
+		delete(skipped, exceptionListKey(item))
+	}
+
+	result := []interface{}{}
+	for i, item := range live {
+		if !skipped[exceptionListKey(item)] {
+			result = append(result, liveLists.([]interface{})[i])
+		}
+	}
+	return result
+}
+
+// planExceptionListIds looks up the IDs of the exception containers the configuration references by list ID
+// only. The lookup runs on every plan, so a container recreated with the same list ID updates the rule. The
+// ID of a container that does not exist yet, e.g. one created in the same apply, is left unknown.
+func planExceptionListIds(ctx context.Context, client *helpers.Client, spaceID string, config, plan []ExceptionListModel, diags *diag.Diagnostics) {
+	for i := range plan {
+		if i >= len(config) || !config[i].Id.IsNull() {
+			continue
+		}
+		list := &plan[i]
+		list.Id = types.StringUnknown()
+		if client == nil || list.ListId.IsUnknown() || list.NamespaceType.IsUnknown() {
+			continue
+		}
+		id, err := lookupExceptionListId(ctx, client, spaceID, list.ListId.ValueString(), list.NamespaceType.ValueString())
+		if err != nil {
+			if !helpers.IsNotFound(err) {
+				addClientErrorDiagnostic(diags, err, path.Empty())
+			}
+			continue
+		}
+		list.Id = types.StringValue(id)
+	}
+}
+
+// resolveExceptionListIds looks up the IDs the plan left unknown, the containers exist once their
+// dependencies are applied
+func resolveExceptionListIds(ctx context.Context, client *helpers.Client, spaceID string, lists []ExceptionListModel, diags *diag.Diagnostics) {
+	for i := range lists {
+		list := &lists[i]
+		if !list.Id.IsUnknown() {
+			continue
+		}
+		id, err := lookupExceptionListId(ctx, client, spaceID, list.ListId.ValueString(), list.NamespaceType.ValueString())
+		if err != nil {
+			if helpers.IsNotFound(err) {
+				diags.AddError("Exception Container Not Found", fmt.Sprintf(
+					"No exception container with the list ID %q and the namespace type %q exists.",
+					list.ListId.ValueString(), list.NamespaceType.ValueString()))
+				continue
+			}
+			addClientErrorDiagnostic(diags, err, path.Empty())
+			continue
+		}
+		list.Id = types.StringValue(id)
+	}
+}
+
+// lookupExceptionListId returns the ID of the exception container with the list ID in the namespace
+func lookupExceptionListId(ctx context.Context, client *helpers.Client, spaceID, listID, namespaceType string) (string, error) {
+	apiPath := fmt.Sprintf("/exception_lists?list_id=%s&namespace_type=%s", url.QueryEscape(listID), url.QueryEscape(namespaceType))
+	var response transferobjects.ExceptionContainerResponse
+	if err := client.WithSpace(spaceID).Get(ctx, apiPath, &response); err != nil {
+		return "", err
+	}
+	return response.ID, nil
+}
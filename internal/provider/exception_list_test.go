@@ -0,0 +1,51 @@
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"terraform-provider-elastic-siem/internal/provider/transferobjects"
+	"testing"
+)
+
+func TestMergeExceptionLists(t *testing.T) {
+	content := []transferobjects.ExceptionListItem{
+		{ID: "a", ListID: "shared", Type: "detection", NamespaceType: "single"},
+		{ID: "b", ListID: "endpoint_list", Type: "endpoint", NamespaceType: "agnostic"},
+	}
+	attached := []transferobjects.ExceptionListItem{
+		{ID: "c", ListID: "rule-default", Type: "rule_default", NamespaceType: "single"},
+		{ID: "d", ListID: "shared", Type: "detection", NamespaceType: "single"},
+		{ID: "e", ListID: "shared", Type: "detection", NamespaceType: "agnostic"},
+	}
+
+	var ids []string
+	for _, item := range mergeExceptionLists(content, attached) {
+		ids = append(ids, item.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"d", "b", "c", "e"}) {
+		t.Errorf("expected the attached containers to replace those of the content in place, got %v", ids)
+	}
+	if merged := mergeExceptionLists(nil, nil); merged != nil {
+		t.Errorf("expected no exception list, got %v", merged)
+	}
+}
+
+func TestWithoutAttachedExceptionLists(t *testing.T) {
+	var live interface{}
+	json.Unmarshal([]byte(`[{"id":"a","list_id":"shared","type":"detection","namespace_type":"single"},`+
+		`{"id":"c","list_id":"rule-default","type":"rule_default","namespace_type":"single"},`+
+		`{"id":"x","list_id":"added-in-kibana","type":"detection","namespace_type":"single"}]`), &live)
+	attached := []transferobjects.ExceptionListItem{
+		{ID: "a", ListID: "shared", Type: "detection", NamespaceType: "single"},
+		{ID: "c", ListID: "rule-default", Type: "rule_default", NamespaceType: "single"},
+	}
+	var content interface{}
+	json.Unmarshal([]byte(`[{"id":"a","list_id":"shared","type":"detection","namespace_type":"single"}]`), &content)
+
+	result, _ := json.Marshal(withoutAttachedExceptionLists(live, attached, content))
+	expected := `[{"id":"a","list_id":"shared","namespace_type":"single","type":"detection"},` +
+		`{"id":"x","list_id":"added-in-kibana","namespace_type":"single","type":"detection"}]`
+	if string(result) != expected {
+		t.Errorf("unexpected exception lists: %s", result)
+	}
+}
@@ -0,0 +1,240 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/attr/xattr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"reflect"
+	"terraform-provider-elastic-siem/internal/helpers"
+)
+
+// Ensure the JSON content types fully satisfy framework interfaces
+var _ basetypes.StringTypable = JSONContentType{}
+var _ basetypes.StringValuableWithSemanticEquals = JSONContentValue{}
+var _ xattr.ValidateableAttribute = JSONContentValue{}
+
+// jsonContentKind selects the Kibana defaults and server fields ignored when comparing JSON content
+type jsonContentKind string
+
+const (
+	ruleContentKind          jsonContentKind = "detection_rule"
+	exceptionItemContentKind jsonContentKind = "exception_item"
+)
+
+var (
+	ruleContentType          = JSONContentType{kind: ruleContentKind}
+	exceptionItemContentType = JSONContentType{kind: exceptionItemContentKind}
+)
+
+// jsonContentDefaults are the values Kibana stores for keys the content does not set, a key set to
+// its default is equal to the key being absent
+var jsonContentDefaults = map[jsonContentKind]map[string]interface{}{
+	ruleContentKind: {
+		"enabled":      true,
+		"interval":     "5m",
+		"from":         "now-6m",
+		"to":           "now",
+		"max_signals":  float64(100),
+		"throttle":     "no_actions",
+		"output_index": "",
+		"setup":        "",
+	},
+	exceptionItemContentKind: {
+		"namespace_type": "single",
+	},
+}
+
+// ruleLanguageDefaults are the query languages Kibana stores for a rule type when the content sets none
+var ruleLanguageDefaults = map[string]string{
+	"query":        "kuery",
+	"saved_query":  "kuery",
+	"threshold":    "kuery",
+	"threat_match": "kuery",
+	"new_terms":    "kuery",
+	"eql":          "eql",
+}
+
+// jsonContentServerFields are maintained by Kibana and never compared
+var jsonContentServerFields = map[jsonContentKind][]string{
+	ruleContentKind: ruleServerFields,
+	exceptionItemContentKind: {
+		"id", "_version", "tie_breaker_id", "meta",
+		"created_at", "created_by", "updated_at", "updated_by",
+	},
+}
+
+// JSONContentType is a string attribute holding a JSON object sent to Kibana. Its values are equal when
+// they describe the same object, whatever the formatting, the key order or the Kibana defaults they spell out.
+type JSONContentType struct {
+	basetypes.StringType
+	kind jsonContentKind
+}
+
+func (t JSONContentType) String() string {
+	return "JSONContentType"
+}
+
+func (t JSONContentType) Equal(o attr.Type) bool {
+	other, ok := o.(JSONContentType)
+	return ok && other.kind == t.kind
+}
+
+// NewValue returns a known value of the type
+func (t JSONContentType) NewValue(value string) JSONContentValue {
+	return JSONContentValue{StringValue: basetypes.NewStringValue(value), kind: t.kind}
+}
+
+func (t JSONContentType) ValueType(ctx context.Context) attr.Value {
+	return JSONContentValue{kind: t.kind}
+}
+
+func (t JSONContentType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return JSONContentValue{StringValue: in, kind: t.kind}, nil
+}
+
+func (t JSONContentType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+	stringValuable, diags := t.ValueFromString(ctx, stringValue)
+	if diags.HasError() {
+		return nil, fmt.Errorf("unexpected error converting StringValue to StringValuable: %v", diags)
+	}
+	return stringValuable, nil
+}
+
+// JSONContentValue is a value of JSONContentType
+type JSONContentValue struct {
+	basetypes.StringValue
+	kind jsonContentKind
+}
+
+func (v JSONContentValue) Type(ctx context.Context) attr.Type {
+	return JSONContentType{kind: v.kind}
+}
+
+func (v JSONContentValue) Equal(o attr.Value) bool {
+	other, ok := o.(JSONContentValue)
+	return ok && other.kind == v.kind && v.StringValue.Equal(other.StringValue)
+}
+
+// StringSemanticEquals reports whether both values describe the same object
+func (v JSONContentValue) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	newValue, ok := newValuable.(JSONContentValue)
+	if !ok {
+		diags.AddError("Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T but got value type %T. Please report this issue to the provider developers.", v, newValuable))
+		return false, diags
+	}
+	return v.semanticallyEqual(newValue), diags
+}
+
+func (v JSONContentValue) semanticallyEqual(other JSONContentValue) bool {
+	if v.IsNull() || v.IsUnknown() || other.IsNull() || other.IsUnknown() {
+		return v.Equal(other)
+	}
+	current, err := normalizeJSONContent(v.ValueString(), v.kind)
+	if err != nil {
+		return false
+	}
+	proposed, err := normalizeJSONContent(other.ValueString(), other.kind)
+	if err != nil {
+		return false
+	}
+	return reflect.DeepEqual(current, proposed)
+}
+
+// ValidateAttribute reports content that is not a JSON object
+func (v JSONContentValue) ValidateAttribute(ctx context.Context, req xattr.ValidateAttributeRequest, resp *xattr.ValidateAttributeResponse) {
+	if v.IsNull() || v.IsUnknown() {
+		return
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal([]byte(v.ValueString()), &content); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid JSON Content",
+			fmt.Sprintf("Expected a JSON encoded object, got: %s", err))
+	}
+}
+
+// normalizeJSONContent decodes the content and drops the keys that do not change the object Kibana stores:
+// server fields, empty values and values equal to the Kibana default
+func normalizeJSONContent(content string, kind jsonContentKind) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(content), &result); err != nil {
+		return nil, err
+	}
+	defaults := jsonContentDefaults[kind]
+	for key, value := range result {
+		if helpers.Contains(jsonContentServerFields[kind], key) || isEmptyJSONValue(value) {
+			delete(result, key)
+			continue
+		}
+		if defaultValue, ok := defaults[key]; ok && reflect.DeepEqual(value, defaultValue) {
+			delete(result, key)
+		}
+	}
+	if kind == ruleContentKind {
+		if ruleType, ok := result["type"].(string); ok && result["language"] == ruleLanguageDefaults[ruleType] {
+			delete(result, "language")
+		}
+	}
+	return result, nil
+}
+
+func isEmptyJSONValue(value interface{}) bool {
+	switch typed := value.(type) {
+	case nil:
+		return true
+	case string:
+		// Empty strings are never sent, the rule and exception item structs omit them
+		return typed == ""
+	case []interface{}:
+		return len(typed) == 0
+	case map[string]interface{}:
+		return len(typed) == 0
+	}
+	return false
+}
+
+// suppressEquivalentJSONContent keeps the prior state when the configured content only differs from
+// it in formatting, key order or Kibana defaults, so such a change is not planned as an update
+func suppressEquivalentJSONContent() planmodifier.String {
+	return suppressEquivalentJSONContentModifier{}
+}
+
+type suppressEquivalentJSONContentModifier struct{}
+
+func (m suppressEquivalentJSONContentModifier) Description(ctx context.Context) string {
+	return "Keeps the prior value when the configured JSON content is semantically equal to it."
+}
+
+func (m suppressEquivalentJSONContentModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m suppressEquivalentJSONContentModifier) PlanModifyString(ctx context.Context, req planmodifier.StringRequest, resp *planmodifier.StringResponse) {
+	if req.StateValue.IsNull() || req.PlanValue.IsNull() || req.PlanValue.IsUnknown() {
+		return
+	}
+	var state, plan JSONContentValue
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, req.Path, &state)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, req.Path, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if state.semanticallyEqual(plan) {
+		resp.PlanValue = req.StateValue
+	}
+}
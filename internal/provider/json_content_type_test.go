@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+func TestJSONContentSemanticEquals(t *testing.T) {
+	tests := []struct {
+		name     string
+		kind     JSONContentType
+		current  string
+		proposed string
+		equal    bool
+	}{
+		{"formatting", ruleContentType, `{"name":"My rule","type":"query"}`, "{\n  \"name\": \"My rule\",\n  \"type\": \"query\"\n}", true},
+		{"key order", ruleContentType, `{"name":"My rule","type":"query"}`, `{"type":"query","name":"My rule"}`, true},
+		{"kibana defaults", ruleContentType, `{"name":"My rule"}`, `{"name":"My rule","interval":"5m","max_signals":100,"enabled":true,"actions":[],"meta":{}}`, true},
+		{"server fields", ruleContentType, `{"name":"My rule"}`, `{"name":"My rule","id":"abc","revision":3,"updated_at":"2024-01-01T00:00:00Z"}`, true},
+		{"changed default", ruleContentType, `{"name":"My rule"}`, `{"name":"My rule","interval":"1m"}`, false},
+		{"changed value", ruleContentType, `{"name":"My rule"}`, `{"name":"Other rule"}`, false},
+		{"array order", ruleContentType, `{"tags":["a","b"]}`, `{"tags":["b","a"]}`, false},
+		{"invalid", ruleContentType, `{"name":"My rule"}`, `{"name":`, false},
+		{"exception item defaults", exceptionItemContentType, `{"name":"My item"}`, `{"name":"My item","namespace_type":"single","comments":[],"tie_breaker_id":"x"}`, true},
+		{"exception item namespace", exceptionItemContentType, `{"name":"My item"}`, `{"name":"My item","namespace_type":"agnostic"}`, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			equal, diags := test.kind.NewValue(test.current).StringSemanticEquals(context.Background(), test.kind.NewValue(test.proposed))
+			if diags.HasError() {
+				t.Fatal(diags)
+			}
+			if equal != test.equal {
+				t.Errorf("expected semantic equality %v, got %v", test.equal, equal)
+			}
+		})
+	}
+}
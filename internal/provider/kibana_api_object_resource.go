@@ -0,0 +1,533 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"terraform-provider-elastic-siem/internal/helpers"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &KibanaApiObjectResource{}
+var _ resource.ResourceWithImportState = &KibanaApiObjectResource{}
+var _ resource.ResourceWithModifyPlan = &KibanaApiObjectResource{}
+
+// idPlaceholder is replaced by the object id in the read, update and destroy paths
+const idPlaceholder = "{id}"
+
+func NewKibanaApiObjectResource() resource.Resource {
+	return &KibanaApiObjectResource{}
+}
+
+// KibanaApiObjectResource defines the resource implementation.
+type KibanaApiObjectResource struct {
+	client *helpers.Client
+}
+
+// KibanaApiObjectResourceModel describes the resource data model.
+type KibanaApiObjectResourceModel struct {
+	Path         types.String   `tfsdk:"path"`
+	CreateMethod types.String   `tfsdk:"create_method"`
+	ReadPath     types.String   `tfsdk:"read_path"`
+	UpdatePath   types.String   `tfsdk:"update_path"`
+	UpdateMethod types.String   `tfsdk:"update_method"`
+	DestroyPath  types.String   `tfsdk:"destroy_path"`
+	Data         types.String   `tfsdk:"data"`
+	IdAttribute  types.String   `tfsdk:"id_attribute"`
+	DriftKeys    types.List     `tfsdk:"drift_keys"`
+	ApiResponse  types.String   `tfsdk:"api_response"`
+	SpaceId      types.String   `tfsdk:"space_id"`
+	Id           types.String   `tfsdk:"id"`
+	Timeouts     timeouts.Value `tfsdk:"timeouts"`
+}
+
+func (r *KibanaApiObjectResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_kibana_api_object"
+}
+
+func (r *KibanaApiObjectResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Manages an object of any Kibana API the provider has no dedicated resource for. " +
+			"Paths are relative to `/api` of the Kibana space, e.g. `/detection_engine/rules`. " +
+			"An imported object keeps the object read from Kibana as `data`, without its id and the usual server fields, " +
+			"the other keys Kibana adds must be reconciled with the configuration.",
+
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				MarkdownDescription: "The path the object is created at, e.g. `/actions/connector`",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"create_method": schema.StringAttribute{
+				MarkdownDescription: "The HTTP method used to create the object, defaults to `POST`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("POST"),
+				Validators:          []validator.String{stringvalidator.OneOf("POST", "PUT")},
+			},
+			"read_path": schema.StringAttribute{
+				MarkdownDescription: "The path the object is read from, `{id}` is replaced by the object id. Defaults to `<path>/{id}`",
+				Optional:            true,
+				Computed:            true,
+			},
+			"update_path": schema.StringAttribute{
+				MarkdownDescription: "The path the object is updated at, `{id}` is replaced by the object id. Defaults to `read_path`",
+				Optional:            true,
+				Computed:            true,
+			},
+			"update_method": schema.StringAttribute{
+				MarkdownDescription: "The HTTP method used to update the object, defaults to `PUT`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("PUT"),
+				Validators:          []validator.String{stringvalidator.OneOf("POST", "PUT")},
+			},
+			"destroy_path": schema.StringAttribute{
+				MarkdownDescription: "The path the object is deleted at, `{id}` is replaced by the object id. Defaults to `read_path`",
+				Optional:            true,
+				Computed:            true,
+			},
+			"data": schema.StringAttribute{
+				MarkdownDescription: "The body sent to create and update the object (JSON encoded string)",
+				Required:            true,
+				Sensitive:           true,
+			},
+			"id_attribute": schema.StringAttribute{
+				MarkdownDescription: "The attribute of the response holding the object id, nested attributes are separated by `/`. Defaults to `id`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("id"),
+			},
+			"drift_keys": schema.ListAttribute{
+				MarkdownDescription: "The keys of `data` compared with the object in Kibana, nested keys are separated by `/`. " +
+					"A key changed outside of Terraform is shown as a difference of `data` in the next plan.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"api_response": schema.StringAttribute{
+				MarkdownDescription: "The last response of Kibana for the object (JSON encoded string)",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"space_id": spaceIdAttribute(),
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Object identifier, read from the `id_attribute` of the response",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+func (r *KibanaApiObjectResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = data.client
+}
+
+func (r *KibanaApiObjectResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to resolve on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var data, config *KibanaApiObjectResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The paths derived from `path` or `read_path` follow their changes instead of keeping the state
+	planApiObjectPaths(data, config)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("read_path"), data.ReadPath)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("update_path"), data.UpdatePath)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("destroy_path"), data.DestroyPath)...)
+}
+
+func (r *KibanaApiObjectResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *KibanaApiObjectResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !json.Valid([]byte(data.Data.ValueString())) {
+		resp.Diagnostics.AddAttributeError(path.Root("data"), "Parser Error", "The data is not valid JSON.")
+		return
+	}
+	body := json.RawMessage(data.Data.ValueString())
+
+	// Create the object through the API
+	var response json.RawMessage
+	client := r.client.WithSpace(data.SpaceId.ValueString())
+	var err error
+	if data.CreateMethod.ValueString() == "PUT" {
+		err = client.Put(ctx, data.Path.ValueString(), body, &response, nil)
+	} else {
+		err = client.Post(ctx, data.Path.ValueString(), body, &response, nil)
+	}
+	if err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Root("data"))
+		return
+	}
+
+	// Objects created with PUT often carry their id in the request only
+	id, ok := objectID(data.IdAttribute.ValueString(), response, body)
+	if !ok {
+		resp.Diagnostics.AddAttributeError(path.Root("id_attribute"), "Missing Object Identifier",
+			fmt.Sprintf("Neither the response nor the data hold the attribute %q, the object was created but cannot be managed.", data.IdAttribute.ValueString()))
+		return
+	}
+
+	// Save id into the Terraform state.
+	data.Id = types.StringValue(id)
+	data.ApiResponse = types.StringValue(string(response))
+	resolveApiObjectPaths(data)
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KibanaApiObjectResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *KibanaApiObjectResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Get the object through the API, an imported object has no id yet and is read from the literal read path
+	imported := data.Id.IsNull()
+	readPath := data.ReadPath.ValueString()
+	if !imported {
+		readPath = interpolateApiObjectPath(readPath, data.Id.ValueString())
+	}
+	response, err := r.client.WithSpace(data.SpaceId.ValueString()).GetString(ctx, readPath)
+	if err != nil {
+		if removeMissingResource(ctx, err, resp) {
+			return
+		}
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
+		return
+	}
+	data.ApiResponse = types.StringValue(response)
+
+	if imported {
+		r.importApiObject(data, response, &resp.Diagnostics)
+	} else {
+		var driftKeys []string
+		resp.Diagnostics.Append(data.DriftKeys.ElementsAs(ctx, &driftKeys, false)...)
+		if content, drifted := applyDrift(data.Data.ValueString(), response, driftKeys); drifted {
+			data.Data = types.StringValue(content)
+		}
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+// importApiObject fills the state of an imported object from the object read from Kibana
+func (r *KibanaApiObjectResource) importApiObject(data *KibanaApiObjectResourceModel, response string, diags *diag.Diagnostics) {
+	if data.IdAttribute.IsNull() {
+		data.IdAttribute = types.StringValue("id")
+	}
+	id, ok := objectID(data.IdAttribute.ValueString(), []byte(response))
+	if !ok {
+		diags.AddError("Missing Object Identifier",
+			fmt.Sprintf("The object read from %s has no attribute %q.", data.ReadPath.ValueString(), data.IdAttribute.ValueString()))
+		return
+	}
+	content, err := importedApiObjectData(response, data.IdAttribute.ValueString())
+	if err != nil {
+		diags.AddError("Parser Error", fmt.Sprintf("The object read from %s is not a JSON object: %s", data.ReadPath.ValueString(), err))
+		return
+	}
+	data.Id = types.StringValue(id)
+	data.Data = types.StringValue(content)
+	data.CreateMethod = types.StringValue("POST")
+	data.UpdateMethod = types.StringValue("PUT")
+
+	// `/actions/connector/abc` is read from `/actions/connector/{id}`, created at `/actions/connector`
+	readPath := templateApiObjectPath(data.ReadPath.ValueString(), id)
+	data.ReadPath = types.StringValue(readPath)
+	collectionPath, _, _ := strings.Cut(readPath, "?")
+	data.Path = types.StringValue(strings.TrimSuffix(collectionPath, "/"+idPlaceholder))
+	data.UpdatePath = types.StringNull()
+	data.DestroyPath = types.StringNull()
+	resolveApiObjectPaths(data)
+}
+
+func (r *KibanaApiObjectResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *KibanaApiObjectResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !json.Valid([]byte(data.Data.ValueString())) {
+		resp.Diagnostics.AddAttributeError(path.Root("data"), "Parser Error", "The data is not valid JSON.")
+		return
+	}
+	body := json.RawMessage(data.Data.ValueString())
+	resolveApiObjectPaths(data)
+
+	// Update the object through the API
+	var response json.RawMessage
+	client := r.client.WithSpace(data.SpaceId.ValueString())
+	updatePath := interpolateApiObjectPath(data.UpdatePath.ValueString(), data.Id.ValueString())
+	var err error
+	if data.UpdateMethod.ValueString() == "POST" {
+		err = client.Post(ctx, updatePath, body, &response, nil)
+	} else {
+		err = client.Put(ctx, updatePath, body, &response, nil)
+	}
+	if err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Root("data"))
+		return
+	}
+	data.ApiResponse = types.StringValue(string(response))
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KibanaApiObjectResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data *KibanaApiObjectResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Delete the object through the API
+	destroyPath := interpolateApiObjectPath(data.DestroyPath.ValueString(), data.Id.ValueString())
+	deleteObject(ctx, r.client.WithSpace(data.SpaceId.ValueString()), destroyPath, &resp.Diagnostics)
+}
+
+// ImportState imports an object by its read path, e.g. `/actions/connector/<id>` or `<space_id>/actions/connector/<id>`
+func (r *KibanaApiObjectResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	readPath := req.ID
+	if !strings.HasPrefix(readPath, "/") {
+		spaceID, rest, found := strings.Cut(req.ID, "/")
+		if !found || spaceID == "" || rest == "" {
+			resp.Diagnostics.AddError("Invalid Import Identifier",
+				fmt.Sprintf("Expected an import identifier in the form `<read_path>` or `<space_id>/<read_path>`, got: %s", req.ID))
+			return
+		}
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("space_id"), spaceID)...)
+		readPath = "/" + rest
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("read_path"), readPath)...)
+}
+
+// resolveApiObjectPaths sets the paths which are not configured to their defaults
+func resolveApiObjectPaths(data *KibanaApiObjectResourceModel) {
+	if data.ReadPath.IsNull() || data.ReadPath.IsUnknown() {
+		data.ReadPath = types.StringValue(strings.TrimSuffix(data.Path.ValueString(), "/") + "/" + idPlaceholder)
+	}
+	if data.UpdatePath.IsNull() || data.UpdatePath.IsUnknown() {
+		data.UpdatePath = data.ReadPath
+	}
+	if data.DestroyPath.IsNull() || data.DestroyPath.IsUnknown() {
+		data.DestroyPath = data.ReadPath
+	}
+}
+
+// planApiObjectPaths plans the configured paths and derives the others, leaving them unknown until the path they default to is known
+func planApiObjectPaths(data, config *KibanaApiObjectResourceModel) {
+	data.ReadPath = config.ReadPath
+	data.UpdatePath = config.UpdatePath
+	data.DestroyPath = config.DestroyPath
+	if data.ReadPath.IsNull() {
+		if data.Path.IsUnknown() {
+			data.ReadPath = types.StringUnknown()
+		} else {
+			data.ReadPath = types.StringValue(strings.TrimSuffix(data.Path.ValueString(), "/") + "/" + idPlaceholder)
+		}
+	}
+	if data.UpdatePath.IsNull() {
+		data.UpdatePath = data.ReadPath
+	}
+	if data.DestroyPath.IsNull() {
+		data.DestroyPath = data.ReadPath
+	}
+}
+
+// apiObjectServerFields are maintained by Kibana and left out of the data of an imported object
+var apiObjectServerFields = []string{"created_at", "created_by", "updated_at", "updated_by", "version", "revision"}
+
+// importedApiObjectData returns the object read from Kibana without its id and server fields
+func importedApiObjectData(response, idAttribute string) (string, error) {
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(response), &object); err != nil {
+		return "", err
+	}
+	helpers.DeleteJSONPath(object, idAttribute)
+	for _, key := range apiObjectServerFields {
+		delete(object, key)
+	}
+	content, err := json.Marshal(object)
+	return string(content), err
+}
+
+// templateApiObjectPath replaces the id in the last segment or in a query parameter of a read path by the placeholder,
+// other segments are kept even if they hold the same value
+func templateApiObjectPath(readPath, id string) string {
+	apiPath, query, hasQuery := strings.Cut(readPath, "?")
+	if escapedID := url.PathEscape(id); strings.HasSuffix(apiPath, "/"+escapedID) {
+		apiPath = strings.TrimSuffix(apiPath, escapedID) + idPlaceholder
+	} else if hasQuery {
+		params := strings.Split(query, "&")
+		for i, param := range params {
+			if name, value, _ := strings.Cut(param, "="); value == url.QueryEscape(id) {
+				params[i] = name + "=" + idPlaceholder
+			}
+		}
+		query = strings.Join(params, "&")
+	}
+	if hasQuery {
+		return apiPath + "?" + query
+	}
+	return apiPath
+}
+
+func interpolateApiObjectPath(apiPath, id string) string {
+	return strings.ReplaceAll(apiPath, idPlaceholder, url.PathEscape(id))
+}
+
+// objectID returns the id attribute of the first JSON document holding it
+func objectID(idAttribute string, documents ...[]byte) (string, bool) {
+	for _, document := range documents {
+		var object map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(document))
+		decoder.UseNumber()
+		if err := decoder.Decode(&object); err != nil {
+			continue
+		}
+		if id, ok := helpers.GetJSONPath(object, idAttribute); ok && id != nil {
+			return fmt.Sprint(id), true
+		}
+	}
+	return "", false
+}
+
+// applyDrift copies the values of the drift keys from the live object into the content. The content
+// is only rewritten when a key differs, so an object without drift keeps the formatting of its configuration.
+func applyDrift(content, live string, driftKeys []string) (string, bool) {
+	if len(driftKeys) == 0 {
+		return content, false
+	}
+	var desiredObject, liveObject map[string]interface{}
+	if json.Unmarshal([]byte(content), &desiredObject) != nil || json.Unmarshal([]byte(live), &liveObject) != nil {
+		return content, false
+	}
+
+	drifted := false
+	for _, key := range driftKeys {
+		liveValue, inLive := helpers.GetJSONPath(liveObject, key)
+		desiredValue, inDesired := helpers.GetJSONPath(desiredObject, key)
+		switch {
+		case inLive && (!inDesired || !reflect.DeepEqual(liveValue, desiredValue)):
+			helpers.SetJSONPath(desiredObject, key, liveValue)
+			drifted = true
+		case !inLive && inDesired:
+			helpers.DeleteJSONPath(desiredObject, key)
+			drifted = true
+		}
+	}
+	if !drifted {
+		return content, false
+	}
+	result, err := json.Marshal(desiredObject)
+	if err != nil {
+		return content, false
+	}
+	return string(result), true
+}
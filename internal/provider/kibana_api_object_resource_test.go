@@ -0,0 +1,153 @@
+package provider
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"terraform-provider-elastic-siem/internal/fakeserver"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccKibanaApiObjectResource(t *testing.T) {
+
+	debug := true
+	apiServerObjects := make(map[string]map[string]interface{})
+
+	svr := fakeserver.NewFakeServer(test_post, apiServerObjects, true, debug, "")
+	test_url := fmt.Sprintf(`http://%s:%d`, test_host, test_post)
+	os.Setenv("REST_API_URI", test_url)
+
+	data := `{"id":"1234","name":"first","config":{"enabled":true}}`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			svr.StartInBackground()
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Create and Read testing
+			{
+				Config: testAccKibanaApiObjectResourceConfig(data),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("elastic-siem_kibana_api_object.test", "id", "1234"),
+					resource.TestCheckResourceAttr("elastic-siem_kibana_api_object.test", "read_path", "/objects/{id}"),
+					resource.TestCheckResourceAttr("elastic-siem_kibana_api_object.test", "destroy_path", "/objects/{id}"),
+					resource.TestCheckResourceAttr("elastic-siem_kibana_api_object.test", "data", data),
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:            "elastic-siem_kibana_api_object.test",
+				ImportState:             true,
+				ImportStateId:           "/objects/1234",
+				ImportStateVerify:       true,
+				ImportStateVerifyIgnore: []string{"data", "drift_keys"},
+			},
+			// A drift key changed outside of Terraform is planned to be restored
+			{
+				PreConfig: func() {
+					apiServerObjects["1234"]["name"] = "changed"
+				},
+				Config:             testAccKibanaApiObjectResourceConfig(data),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			// An object deleted outside of Terraform is planned to be created again
+			{
+				PreConfig: func() {
+					delete(apiServerObjects, "1234")
+				},
+				Config:             testAccKibanaApiObjectResourceConfig(data),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			// Update and Read testing
+			{
+				Config: testAccKibanaApiObjectResourceConfig(data),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("elastic-siem_kibana_api_object.test", "data", data),
+					func(s *terraform.State) error {
+						if name := apiServerObjects["1234"]["name"]; name != "first" {
+							return fmt.Errorf("expected the drifted name to be restored, got %v", name)
+						}
+						return nil
+					},
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+
+	svr.Shutdown()
+}
+
+func testAccKibanaApiObjectResourceConfig(data string) string {
+	return fmt.Sprintf(`%s
+resource "elastic-siem_kibana_api_object" "test" {
+  path       = "/objects"
+  data       = %s
+  drift_keys = ["name", "config/enabled"]
+}
+`, providerConfig, strconv.Quote(data))
+}
+
+func TestApplyDrift(t *testing.T) {
+	content := `{"name": "first", "config": {"enabled": true}, "other": 1}`
+	if result, drifted := applyDrift(content, `{"name":"first","config":{"enabled":true},"other":2}`, []string{"name", "config/enabled"}); drifted || result != content {
+		t.Errorf("expected keys outside of the drift keys to be ignored, got %s", result)
+	}
+	result, drifted := applyDrift(content, `{"name":"changed","config":{}}`, []string{"name", "config/enabled"})
+	if !drifted || result != `{"config":{},"name":"changed","other":1}` {
+		t.Errorf("unexpected drift: %s", result)
+	}
+}
+
+func TestPlanApiObjectPaths(t *testing.T) {
+	state := &KibanaApiObjectResourceModel{
+		Path:        types.StringValue("/objects/"),
+		ReadPath:    types.StringValue("/old/{id}"),
+		UpdatePath:  types.StringValue("/old/{id}"),
+		DestroyPath: types.StringValue("/old/{id}"),
+	}
+	planApiObjectPaths(state, &KibanaApiObjectResourceModel{DestroyPath: types.StringValue("/objects/{id}?force=true")})
+	if state.ReadPath.ValueString() != "/objects/{id}" || state.UpdatePath.ValueString() != "/objects/{id}" {
+		t.Errorf("expected the paths to be derived from the new path, got %s and %s", state.ReadPath, state.UpdatePath)
+	}
+	if state.DestroyPath.ValueString() != "/objects/{id}?force=true" {
+		t.Errorf("expected the configured destroy path to be kept, got %s", state.DestroyPath)
+	}
+
+	unknown := &KibanaApiObjectResourceModel{Path: types.StringUnknown()}
+	planApiObjectPaths(unknown, &KibanaApiObjectResourceModel{UpdatePath: types.StringValue("/objects/{id}/_update")})
+	if !unknown.ReadPath.IsUnknown() || !unknown.DestroyPath.IsUnknown() || unknown.UpdatePath.ValueString() != "/objects/{id}/_update" {
+		t.Errorf("expected the derived paths to be unknown until the path is known, got %+v", unknown)
+	}
+}
+
+func TestTemplateApiObjectPath(t *testing.T) {
+	for readPath, expected := range map[string]string{
+		"/actions/connector/abc":                   "/actions/connector/{id}",
+		"/abc/objects/abc":                         "/abc/objects/{id}",
+		"/detection_engine/rules?id=abc&full=abc1": "/detection_engine/rules?id={id}&full=abc1",
+		"/objects/abc?space=abc":                   "/objects/{id}?space=abc",
+	} {
+		if templated := templateApiObjectPath(readPath, "abc"); templated != expected {
+			t.Errorf("expected %s to be templated as %s, got %s", readPath, expected, templated)
+		}
+	}
+}
+
+func TestImportedApiObjectData(t *testing.T) {
+	content, err := importedApiObjectData(`{"id":"abc","name":"slack","config":{"id":"x"},"created_at":"2024-01-01","version":"WzEsMV0="}`, "id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content != `{"config":{"id":"x"},"name":"slack"}` {
+		t.Errorf("expected the id and server fields to be dropped, got %s", content)
+	}
+}
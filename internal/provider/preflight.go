@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"sort"
+	"strings"
+	"terraform-provider-elastic-siem/internal/helpers"
+	"terraform-provider-elastic-siem/internal/provider/transferobjects"
+)
+
+// preflight checks that the configured credentials can manage detection rules before any resource
+// is planned, so a wrong password or a missing privilege is reported once by the provider instead
+// of as a failed request of every resource.
+func preflight(ctx context.Context, client *helpers.Client, diags *diag.Diagnostics) {
+	var response transferobjects.PrivilegesResponse
+	if err := client.Get(ctx, "/detection_engine/privileges", &response); err != nil {
+		if helpers.IsUnauthorized(err) {
+			diags.AddError("Kibana Authentication Failed",
+				fmt.Sprintf("The preflight check could not authenticate to Kibana, verify the configured credentials.\n\n%s", err))
+			return
+		}
+		addClientErrorDiagnostic(diags, err, path.Empty())
+		return
+	}
+
+	if !response.IsAuthenticated {
+		diags.AddError("Kibana Authentication Failed",
+			"The preflight check could not authenticate to Kibana, verify the configured credentials.")
+		return
+	}
+	if !response.HasEncryptionKey {
+		diags.AddError("Missing Encryption Key",
+			"Kibana has no `xpack.encryptedSavedObjects.encryptionKey` configured, detection rules cannot be created without it.")
+	}
+
+	// Enabling the detection engine in a space, which happens when its first rule is written, manages the
+	// alerts index templates of the cluster
+	if !response.Cluster.All && !response.Cluster.Manage {
+		diags.AddError("Missing Privileges",
+			fmt.Sprintf("The user %q lacks the `manage` cluster privilege, which is required to write detection rules.", response.Username))
+	}
+
+	// The response holds the alerts index of the space, e.g. `.alerts-security.alerts-default`
+	if len(response.Index) == 0 {
+		diags.AddError("Missing Privileges",
+			fmt.Sprintf("Kibana reported no privileges of the user %q on the alerts index of the space, which are required to manage detection rules and their alerts.", response.Username))
+		return
+	}
+	indices := make([]string, 0, len(response.Index))
+	for index := range response.Index {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+	for _, index := range indices {
+		if missing := missingIndexPrivileges(response.Index[index]); len(missing) > 0 {
+			diags.AddError("Missing Privileges",
+				fmt.Sprintf("The user %q lacks the %s privileges on the alerts index %s, which are required to manage detection rules and their alerts.",
+					response.Username, strings.Join(missing, " and "), index))
+		}
+	}
+}
+
+// missingIndexPrivileges returns the privileges on the alerts index the detection engine needs but the user lacks
+func missingIndexPrivileges(privileges transferobjects.IndexPrivileges) []string {
+	if privileges.All {
+		return nil
+	}
+	var missing []string
+	if !privileges.Read {
+		missing = append(missing, "`read`")
+	}
+	if !privileges.Write && !privileges.Index && !privileges.CreateDoc && !privileges.Create {
+		missing = append(missing, "`write`")
+	}
+	return missing
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"terraform-provider-elastic-siem/internal/helpers"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+func TestPreflight(t *testing.T) {
+	cases := []struct {
+		name        string
+		statusCode  int
+		body        string
+		wantSummary string
+	}{
+		{
+			name:       "ready",
+			statusCode: http.StatusOK,
+			body:       `{"username":"elastic","is_authenticated":true,"has_encryption_key":true,"cluster":{"manage":true},"index":{".alerts-security.alerts-default":{"read":true,"write":true}}}`,
+		},
+		{
+			name:       "all privileges",
+			statusCode: http.StatusOK,
+			body:       `{"username":"elastic","is_authenticated":true,"has_encryption_key":true,"cluster":{"manage":true},"index":{".alerts-security.alerts-default":{"all":true}}}`,
+		},
+		{
+			name:        "wrong password",
+			statusCode:  http.StatusUnauthorized,
+			body:        `{"statusCode":401,"error":"Unauthorized","message":"[security_exception]: unable to authenticate user"}`,
+			wantSummary: "Kibana Authentication Failed",
+		},
+		{
+			name:        "not authenticated",
+			statusCode:  http.StatusOK,
+			body:        `{"is_authenticated":false}`,
+			wantSummary: "Kibana Authentication Failed",
+		},
+		{
+			name:        "no encryption key",
+			statusCode:  http.StatusOK,
+			body:        `{"username":"elastic","is_authenticated":true,"has_encryption_key":false,"cluster":{"manage":true},"index":{".alerts-security.alerts-default":{"all":true}}}`,
+			wantSummary: "Missing Encryption Key",
+		},
+		{
+			name:        "no cluster privileges",
+			statusCode:  http.StatusOK,
+			body:        `{"username":"analyst","is_authenticated":true,"has_encryption_key":true,"cluster":{"monitor":true},"index":{".alerts-security.alerts-default":{"all":true}}}`,
+			wantSummary: "Missing Privileges",
+		},
+		{
+			name:        "no alerts index",
+			statusCode:  http.StatusOK,
+			body:        `{"username":"elastic","is_authenticated":true,"has_encryption_key":true,"cluster":{"all":true},"index":{}}`,
+			wantSummary: "Missing Privileges",
+		},
+		{
+			name:        "missing index entry",
+			statusCode:  http.StatusOK,
+			body:        `{"username":"elastic","is_authenticated":true,"has_encryption_key":true,"cluster":{"all":true}}`,
+			wantSummary: "Missing Privileges",
+		},
+		{
+			name:        "read only",
+			statusCode:  http.StatusOK,
+			body:        `{"username":"viewer","is_authenticated":true,"has_encryption_key":true,"cluster":{"manage":true},"index":{".alerts-security.alerts-team-a":{"read":true}}}`,
+			wantSummary: "Missing Privileges",
+		},
+	}
+	for _, c := range cases {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/api/detection_engine/privileges" {
+				http.NotFound(w, r)
+				return
+			}
+			w.WriteHeader(c.statusCode)
+			w.Write([]byte(c.body))
+		}))
+
+		serverURL, _ := url.Parse(server.URL)
+		port, _ := strconv.Atoi(serverURL.Port())
+		client, err := helpers.NewClient(&helpers.NewClientInput{Hostname: serverURL.Hostname(), Port: port})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var diags diag.Diagnostics
+		preflight(context.Background(), client, &diags)
+		server.Close()
+
+		if c.wantSummary == "" {
+			if diags.HasError() {
+				t.Errorf("%s: expected no errors, got: %v", c.name, diags)
+			}
+			continue
+		}
+		if diags.ErrorsCount() != 1 || diags.Errors()[0].Summary() != c.wantSummary {
+			t.Errorf("%s: expected a %q error, got: %v", c.name, c.wantSummary, diags)
+		}
+	}
+}
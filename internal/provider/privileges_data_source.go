@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
@@ -53,18 +54,18 @@ func (d *PrivilegesDataSource) Configure(ctx context.Context, req datasource.Con
 		return
 	}
 
-	client, ok := req.ProviderData.(*helpers.Client)
+	data, ok := req.ProviderData.(*providerData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Data Source Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	d.client = client
+	d.client = data.client
 }
 
 func (d *PrivilegesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
@@ -79,8 +80,8 @@ func (d *PrivilegesDataSource) Read(ctx context.Context, req datasource.ReadRequ
 
 	// Get the privileges through the API
 	var response transferobjects.PrivilegesResponse
-	if err := d.client.Get("/detection_engine/privileges", &response); err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Error during request, got error: %s", err))
+	if err := d.client.Get(ctx, "/detection_engine/privileges", &response); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
 		return
 	}
 
@@ -109,6 +109,8 @@ func TestAccPrivilegesDataSource(t *testing.T) {
 			},
 		},
 	})
+
+	svr.Shutdown()
 }
 
 func testAccPrivilegesDataSourceConfig(name string) string {
@@ -2,17 +2,39 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"os"
+	"strconv"
+	"strings"
 	"terraform-provider-elastic-siem/internal/helpers"
+	"time"
 )
 
 // Ensure ElasticSiemProvider satisfies various provider interfaces.
 var _ provider.Provider = &ElasticSiemProvider{}
 
+// defaultOperationTimeout applies to resource operations without a configured timeout
+const defaultOperationTimeout = 20 * time.Minute
+
+// withTimeout bounds the context of a resource operation by its configured timeout, e.g. data.Timeouts.Create.
+// A timeout which cannot be read is reported in diags and the default timeout applies.
+func withTimeout(ctx context.Context, timeout func(context.Context, time.Duration) (time.Duration, diag.Diagnostics), diags *diag.Diagnostics) (context.Context, context.CancelFunc) {
+	operationTimeout, timeoutDiags := timeout(ctx, defaultOperationTimeout)
+	diags.Append(timeoutDiags...)
+	return context.WithTimeout(ctx, operationTimeout)
+}
+
 // ElasticSiemProvider defines the provider implementation.
 type ElasticSiemProvider struct {
 	// version is set to the provider version on release, "dev" when the
@@ -23,11 +45,33 @@ type ElasticSiemProvider struct {
 
 // ScaffoldingProviderModel describes the provider data model.
 type ScaffoldingProviderModel struct {
-	Hostname types.String `tfsdk:"hostname"`
-	UseTLS   types.Bool   `tfsdk:"tls"`
-	Port     types.Int64  `tfsdk:"port"`
-	Username types.String `tfsdk:"user"`
-	Password types.String `tfsdk:"password"`
+	Hostname              types.String       `tfsdk:"hostname"`
+	Endpoints             types.List         `tfsdk:"endpoints"`
+	EndpointCooldown      types.Int64        `tfsdk:"endpoint_cooldown"`
+	UseTLS                types.Bool         `tfsdk:"tls"`
+	Port                  types.Int64        `tfsdk:"port"`
+	Username              types.String       `tfsdk:"user"`
+	Password              types.String       `tfsdk:"password"`
+	ApiKey                types.String       `tfsdk:"api_key"`
+	BearerToken           types.String       `tfsdk:"bearer_token"`
+	ServiceToken          types.String       `tfsdk:"service_token"`
+	CloudID               types.String       `tfsdk:"cloud_id"`
+	SpaceID               types.String       `tfsdk:"space_id"`
+	CAFile                types.String       `tfsdk:"ca_file"`
+	CAPEM                 types.String       `tfsdk:"ca_pem"`
+	ClientCert            types.String       `tfsdk:"client_cert"`
+	ClientKey             types.String       `tfsdk:"client_key"`
+	InsecureSkipVerify    types.Bool         `tfsdk:"insecure_skip_verify"`
+	MaxRetries            types.Int64        `tfsdk:"max_retries"`
+	RetryWaitMax          types.Int64        `tfsdk:"retry_wait_max"`
+	RequestTimeout        types.Int64        `tfsdk:"request_timeout"`
+	ProxyURL              types.String       `tfsdk:"proxy_url"`
+	Headers               types.Map          `tfsdk:"headers"`
+	MaxRequestsPerSecond  types.Float64      `tfsdk:"max_requests_per_second"`
+	MaxConcurrentRequests types.Int64        `tfsdk:"max_concurrent_requests"`
+	Preflight             types.Bool         `tfsdk:"preflight"`
+	DefaultTags           types.List         `tfsdk:"default_tags"`
+	RuleDefaults          *RuleDefaultsModel `tfsdk:"rule_defaults"`
 }
 
 func (p *ElasticSiemProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -39,27 +83,190 @@ func (p *ElasticSiemProvider) Schema(ctx context.Context, req provider.SchemaReq
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
 			"hostname": schema.StringAttribute{
-				MarkdownDescription: "The Kibana host name",
-				Required:            true,
+				MarkdownDescription: "The Kibana host name (can be set with the `ELASTIC_SIEM_HOSTNAME` environment variable)",
+				Optional:            true,
+			},
+			"endpoints": schema.ListAttribute{
+				MarkdownDescription: "The URLs of several Kibana nodes, e.g. `https://kibana-1:5601`. Requests go to the first healthy node " +
+					"and fail over to the next one when a node cannot be reached or answers with a 5xx response, `POST` requests only on a 503 as the node may have applied them (can be set as a comma separated list " +
+					"with the `ELASTIC_SIEM_ENDPOINTS` environment variable, conflicts with `hostname` and `cloud_id`, `port` and `tls` are ignored)",
+				ElementType: types.StringType,
+				Optional:    true,
+				Validators:  []validator.List{listvalidator.SizeAtLeast(1)},
+			},
+			"endpoint_cooldown": schema.Int64Attribute{
+				MarkdownDescription: "The number of seconds a failed Kibana node is skipped before it is tried again, defaults to 60 " +
+					"(can be set with the `ELASTIC_SIEM_ENDPOINT_COOLDOWN` environment variable)",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.AtLeast(1)},
+			},
+			"cloud_id": schema.StringAttribute{
+				MarkdownDescription: "The Elastic Cloud ID of the deployment, used to derive the Kibana host, port and TLS settings " +
+					"(can be set with the `ELASTIC_SIEM_CLOUD_ID` environment variable, conflicts with `hostname`)",
+				Optional: true,
+			},
+			"space_id": schema.StringAttribute{
+				MarkdownDescription: "The Kibana space used by all resources that do not set their own `space_id`, " +
+					"defaults to the `default` space (can be set with the `ELASTIC_SIEM_SPACE_ID` environment variable)",
+				Optional: true,
 			},
 			"tls": schema.BoolAttribute{
-				MarkdownDescription: "Connect to host using TLS or unencrypted",
+				MarkdownDescription: "Connect to host using TLS or unencrypted (can be set with the `ELASTIC_SIEM_TLS` environment variable, " +
+					"which is ignored with `cloud_id`)",
+				Optional: true,
+			},
+			"ca_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a PEM encoded CA certificate used to verify Kibana, in addition to the system roots " +
+					"(can be set with the `ELASTIC_SIEM_CA_FILE` environment variable, conflicts with `ca_pem`)",
+				Optional: true,
+			},
+			"ca_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded CA certificate used to verify Kibana, in addition to the system roots (conflicts with `ca_file`)",
 				Optional:            true,
 			},
+			"client_cert": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded client certificate, or the path to one, used for mutual TLS " +
+					"(can be set with the `ELASTIC_SIEM_CLIENT_CERT` environment variable)",
+				Optional: true,
+			},
+			"client_key": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded client key, or the path to one, used for mutual TLS " +
+					"(can be set with the `ELASTIC_SIEM_CLIENT_KEY` environment variable)",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"insecure_skip_verify": schema.BoolAttribute{
+				MarkdownDescription: "Skip the verification of the Kibana server certificate, only use this for testing " +
+					"(can be set with the `ELASTIC_SIEM_INSECURE_SKIP_VERIFY` environment variable)",
+				Optional: true,
+			},
 			"port": schema.Int64Attribute{
-				MarkdownDescription: "Connect to host on a custom port",
+				MarkdownDescription: "Connect to host on a custom port (can be set with the `ELASTIC_SIEM_PORT` environment variable, " +
+					"which is ignored with `cloud_id`)",
+				Optional: true,
+			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: "How often a request failing with a transient error (429, 502, 503, 504 or a connection error) is retried, " +
+					"defaults to 3 (can be set with the `ELASTIC_SIEM_MAX_RETRIES` environment variable)",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.AtLeast(0)},
+			},
+			"retry_wait_max": schema.Int64Attribute{
+				MarkdownDescription: "The maximum number of seconds to wait between two retries, defaults to 30. A longer `Retry-After` of Kibana is honoured " +
+					"(can be set with the `ELASTIC_SIEM_RETRY_WAIT_MAX` environment variable)",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.AtLeast(1)},
+			},
+			"request_timeout": schema.Int64Attribute{
+				MarkdownDescription: "The timeout of a single request to Kibana in seconds, defaults to 10 " +
+					"(can be set with the `ELASTIC_SIEM_REQUEST_TIMEOUT` environment variable)",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.AtLeast(1)},
+			},
+			"proxy_url": schema.StringAttribute{
+				MarkdownDescription: "The proxy used to connect to Kibana, credentials can be passed as user info of the URL. " +
+					"Defaults to the `HTTPS_PROXY` environment variable, `NO_PROXY` is honoured in both cases " +
+					"(can be set with the `ELASTIC_SIEM_PROXY_URL` environment variable)",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"headers": schema.MapAttribute{
+				MarkdownDescription: "Additional headers sent with every request, e.g. tenant headers required by a gateway. Their values are not logged",
+				ElementType:         types.StringType,
 				Optional:            true,
+				Sensitive:           true,
+			},
+			"max_requests_per_second": schema.Float64Attribute{
+				MarkdownDescription: "The maximum number of requests per second sent to Kibana by all resources and data sources, " +
+					"unlimited by default (can be set with the `ELASTIC_SIEM_MAX_REQUESTS_PER_SECOND` environment variable)",
+				Optional:   true,
+				Validators: []validator.Float64{float64validator.AtLeast(0)},
+			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				MarkdownDescription: "The maximum number of requests sent to Kibana at the same time by all resources and data sources, " +
+					"unlimited by default (can be set with the `ELASTIC_SIEM_MAX_CONCURRENT_REQUESTS` environment variable)",
+				Optional:   true,
+				Validators: []validator.Int64{int64validator.AtLeast(0)},
+			},
+			"preflight": schema.BoolAttribute{
+				MarkdownDescription: "Check during the provider configuration that the credentials are valid, Kibana has an encryption key " +
+					"and the user has the cluster and alerts index privileges needed to write rules, defaults to `false` (can be set with the `ELASTIC_SIEM_PREFLIGHT` environment variable)",
+				Optional: true,
+			},
+			"default_tags": schema.ListAttribute{
+				MarkdownDescription: "Tags added to every detection rule, exception container and exception item, " +
+					"the merged tags are shown in `effective_rule_content` and `tags_all`",
+				ElementType: types.StringType,
+				Optional:    true,
 			},
 			"user": schema.StringAttribute{
-				MarkdownDescription: "The username to authenticate to Kiaba and interact with the SIEM",
-				Required:            true,
+				MarkdownDescription: "The username to authenticate to Kiaba and interact with the SIEM (basic auth, can be set with the `ELASTIC_SIEM_USER` environment variable)",
+				Optional:            true,
 				Sensitive:           true,
 			},
 			"password": schema.StringAttribute{
-				MarkdownDescription: "The password to authenticate to Kiaba and interact with the SIEM",
-				Required:            true,
+				MarkdownDescription: "The password to authenticate to Kiaba and interact with the SIEM (basic auth, can be set with the `ELASTIC_SIEM_PASSWORD` environment variable)",
+				Optional:            true,
 				Sensitive:           true,
 			},
+			"api_key": schema.StringAttribute{
+				MarkdownDescription: "An Elasticsearch API key (base64 encoded `id:api_key`), conflicts with the other authentication methods " +
+					"(can be set with the `ELASTIC_SIEM_API_KEY` environment variable)",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"bearer_token": schema.StringAttribute{
+				MarkdownDescription: "A bearer token (e.g. an OAuth2 or Elasticsearch access token), conflicts with the other authentication methods " +
+					"(can be set with the `ELASTIC_SIEM_BEARER_TOKEN` environment variable)",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"service_token": schema.StringAttribute{
+				MarkdownDescription: "An Elastic service account token, conflicts with the other authentication methods " +
+					"(can be set with the `ELASTIC_SIEM_SERVICE_TOKEN` environment variable)",
+				Optional:  true,
+				Sensitive: true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"rule_defaults": schema.SingleNestedBlock{
+				MarkdownDescription: "Defaults for the detection rules, used for the fields a `rule_content` does not set. " +
+					"The merged rule is shown in the `effective_rule_content` attribute of the rule.",
+				Attributes: map[string]schema.Attribute{
+					"author": schema.ListAttribute{
+						MarkdownDescription: "The authors of the rules",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"license": schema.StringAttribute{
+						MarkdownDescription: "The license of the rules",
+						Optional:            true,
+					},
+					"index": schema.ListAttribute{
+						MarkdownDescription: "The index patterns searched by the rules, not used for ES|QL and machine learning rules",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+					"interval": schema.StringAttribute{
+						MarkdownDescription: "How often the rules run, e.g. `5m`",
+						Optional:            true,
+					},
+					"from": schema.StringAttribute{
+						MarkdownDescription: "The start of the time range searched by the rules, e.g. `now-6m`",
+						Optional:            true,
+					},
+					"max_signals": schema.Int64Attribute{
+						MarkdownDescription: "The maximum number of alerts a rule creates per run",
+						Optional:            true,
+						Validators:          []validator.Int64{int64validator.AtLeast(1)},
+					},
+					"tags": schema.ListAttribute{
+						MarkdownDescription: "Tags added to every rule, in addition to `default_tags`",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+				},
+			},
 		},
 	}
 }
@@ -76,43 +283,303 @@ func (p *ElasticSiemProvider) Configure(ctx context.Context, req provider.Config
 	// Configuration values are now available.
 	// if data.Endpoint.IsNull() { /* ... */ }
 
-	hostname := "localhost"
 	port := 443
 	useTls := true
-	username := "elastic"
-	password := "elastic"
 
-	if !data.Hostname.IsNull() {
-		hostname = data.Hostname.ValueString()
+	// An explicit hostname, cloud id or list of endpoints takes precedence over the environment
+	configuredEndpoints := 0
+	for _, value := range []interface{ IsNull() bool }{data.Hostname, data.CloudID, data.Endpoints} {
+		if !value.IsNull() {
+			configuredEndpoints++
+		}
+	}
+	if configuredEndpoints > 1 {
+		resp.Diagnostics.AddError("Conflicting Kibana Endpoint",
+			"Only one of `hostname`, `cloud_id` and `endpoints` can be set.")
+		return
+	}
+	hostname := data.Hostname.ValueString()
+	cloudID := data.CloudID.ValueString()
+	var endpoints []string
+	resp.Diagnostics.Append(data.Endpoints.ElementsAs(ctx, &endpoints, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if configuredEndpoints == 0 {
+		hostname = os.Getenv("ELASTIC_SIEM_HOSTNAME")
+		if hostname == "" {
+			cloudID = os.Getenv("ELASTIC_SIEM_CLOUD_ID")
+		}
+		if hostname == "" && cloudID == "" && os.Getenv("ELASTIC_SIEM_ENDPOINTS") != "" {
+			for _, endpoint := range strings.Split(os.Getenv("ELASTIC_SIEM_ENDPOINTS"), ",") {
+				endpoints = append(endpoints, strings.TrimSpace(endpoint))
+			}
+		}
+	}
+
+	if cloudID != "" {
+		endpoint, err := helpers.DecodeCloudID(cloudID)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("cloud_id"), "Invalid Cloud ID",
+				fmt.Sprintf("Unable to decode the Elastic Cloud ID, got error: %s", err))
+			return
+		}
+		hostname = endpoint.Hostname
+		port = endpoint.Port
+		useTls = endpoint.UseTls
+	}
+
+	if hostname == "" && len(endpoints) == 0 {
+		resp.Diagnostics.AddError("Missing Kibana Endpoint",
+			"One of `hostname`, `cloud_id` or `endpoints` must be set, either in the configuration or through the "+
+				"ELASTIC_SIEM_HOSTNAME, ELASTIC_SIEM_CLOUD_ID or ELASTIC_SIEM_ENDPOINTS environment variables.")
+		return
 	}
 
-	if !data.Port.IsNull() {
-		port = int(data.Port.ValueInt64())
+	if cloudID == "" {
+		port = int(int64ValueOrEnv(&resp.Diagnostics, data.Port, "port", "ELASTIC_SIEM_PORT", int64(port)))
+		useTls = boolValueOrEnv(&resp.Diagnostics, data.UseTLS, "tls", "ELASTIC_SIEM_TLS", useTls)
+	} else {
+		// The cloud ID holds the port and scheme of the deployment, only the configuration overrides them
+		if !data.Port.IsNull() {
+			port = int(data.Port.ValueInt64())
+		}
+		if !data.UseTLS.IsNull() {
+			useTls = data.UseTLS.ValueBool()
+		}
 	}
+	insecureSkipVerify := boolValueOrEnv(&resp.Diagnostics, data.InsecureSkipVerify, "insecure_skip_verify", "ELASTIC_SIEM_INSECURE_SKIP_VERIFY", false)
+	maxRetries := int64ValueOrEnv(&resp.Diagnostics, data.MaxRetries, "max_retries", "ELASTIC_SIEM_MAX_RETRIES", 3)
+	retryWaitMax := int64ValueOrEnv(&resp.Diagnostics, data.RetryWaitMax, "retry_wait_max", "ELASTIC_SIEM_RETRY_WAIT_MAX", 30)
+	maxRequestsPerSecond := float64ValueOrEnv(&resp.Diagnostics, data.MaxRequestsPerSecond, "max_requests_per_second", "ELASTIC_SIEM_MAX_REQUESTS_PER_SECOND", 0)
+	maxConcurrentRequests := int64ValueOrEnv(&resp.Diagnostics, data.MaxConcurrentRequests, "max_concurrent_requests", "ELASTIC_SIEM_MAX_CONCURRENT_REQUESTS", 0)
+	endpointCooldown := int64ValueOrEnv(&resp.Diagnostics, data.EndpointCooldown, "endpoint_cooldown", "ELASTIC_SIEM_ENDPOINT_COOLDOWN", 60)
+	runPreflight := boolValueOrEnv(&resp.Diagnostics, data.Preflight, "preflight", "ELASTIC_SIEM_PREFLIGHT", false)
+	requestTimeout := int64ValueOrEnv(&resp.Diagnostics, data.RequestTimeout, "request_timeout", "ELASTIC_SIEM_REQUEST_TIMEOUT", 10)
 
-	if !data.UseTLS.IsNull() {
-		useTls = data.UseTLS.ValueBool()
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	if !data.Username.IsNull() {
-		username = data.Username.ValueString()
+	// Values of the environment variables bypass the validators of the schema
+	for _, setting := range []struct {
+		attribute, env string
+		value, minimum int64
+	}{
+		{"max_retries", "ELASTIC_SIEM_MAX_RETRIES", maxRetries, 0},
+		{"retry_wait_max", "ELASTIC_SIEM_RETRY_WAIT_MAX", retryWaitMax, 1},
+		{"request_timeout", "ELASTIC_SIEM_REQUEST_TIMEOUT", requestTimeout, 1},
+		{"endpoint_cooldown", "ELASTIC_SIEM_ENDPOINT_COOLDOWN", endpointCooldown, 1},
+		{"max_concurrent_requests", "ELASTIC_SIEM_MAX_CONCURRENT_REQUESTS", maxConcurrentRequests, 0},
+	} {
+		if setting.value < setting.minimum {
+			resp.Diagnostics.AddAttributeError(path.Root(setting.attribute), "Invalid Environment Variable",
+				fmt.Sprintf("%s must be at least %d, got: %d", setting.env, setting.minimum, setting.value))
+		}
+	}
+	if maxRequestsPerSecond < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("max_requests_per_second"), "Invalid Environment Variable",
+			fmt.Sprintf("ELASTIC_SIEM_MAX_REQUESTS_PER_SECOND must be at least 0, got: %g", maxRequestsPerSecond))
 	}
 
-	if !data.Password.IsNull() {
-		password = data.Password.ValueString()
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !data.CAFile.IsNull() && !data.CAPEM.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("ca_pem"), "Conflicting CA Certificate",
+			"Only one of `ca_file` and `ca_pem` can be set.")
+		return
+	}
+	caCertificate := data.CAPEM.ValueString()
+	if caCertificate == "" {
+		caCertificate = data.CAFile.ValueString()
+	}
+	if caCertificate == "" {
+		caCertificate = os.Getenv("ELASTIC_SIEM_CA_FILE")
+	}
+	clientCert := data.ClientCert.ValueString()
+	if data.ClientCert.IsNull() {
+		clientCert = os.Getenv("ELASTIC_SIEM_CLIENT_CERT")
+	}
+	clientKey := data.ClientKey.ValueString()
+	if data.ClientKey.IsNull() {
+		clientKey = os.Getenv("ELASTIC_SIEM_CLIENT_KEY")
+	}
+
+	proxyURL := data.ProxyURL.ValueString()
+	if data.ProxyURL.IsNull() {
+		proxyURL = os.Getenv("ELASTIC_SIEM_PROXY_URL")
+	}
+
+	headers := make(map[string]string)
+	resp.Diagnostics.Append(data.Headers.ElementsAs(ctx, &headers, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	spaceID := data.SpaceID.ValueString()
+	if data.SpaceID.IsNull() {
+		spaceID = os.Getenv("ELASTIC_SIEM_SPACE_ID")
+	}
+
+	// Each credential falls back to its environment variable, so a secret can be exported while the rest
+	// is configured. Credentials of different authentication methods are rejected below.
+	username := stringValueOrEnv(data.Username, "ELASTIC_SIEM_USER")
+	password := stringValueOrEnv(data.Password, "ELASTIC_SIEM_PASSWORD")
+	apiKey := stringValueOrEnv(data.ApiKey, "ELASTIC_SIEM_API_KEY")
+	bearerToken := stringValueOrEnv(data.BearerToken, "ELASTIC_SIEM_BEARER_TOKEN")
+	serviceToken := stringValueOrEnv(data.ServiceToken, "ELASTIC_SIEM_SERVICE_TOKEN")
+
+	validateAuthentication(&resp.Diagnostics, username, password, apiKey, bearerToken, serviceToken)
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
 	// Example client configuration for data sources and resources
-	client := helpers.NewClient(&helpers.NewClientInput{
-		Hostname: hostname,
-		Port:     port,
-		UseTls:   useTls,
-		Username: username,
-		Password: password,
+	client, err := helpers.NewClient(&helpers.NewClientInput{
+		Hostname:              hostname,
+		Port:                  port,
+		UseTls:                useTls,
+		Endpoints:             endpoints,
+		EndpointCooldown:      time.Duration(endpointCooldown) * time.Second,
+		Username:              username,
+		Password:              password,
+		ApiKey:                apiKey,
+		BearerToken:           bearerToken,
+		ServiceToken:          serviceToken,
+		SpaceID:               spaceID,
+		CACertificate:         caCertificate,
+		ClientCertificate:     clientCert,
+		ClientKey:             clientKey,
+		InsecureSkipVerify:    insecureSkipVerify,
+		MaxRetries:            int(maxRetries),
+		RetryWaitMax:          time.Duration(retryWaitMax) * time.Second,
+		Timeout:               time.Duration(requestTimeout) * time.Second,
+		ProxyURL:              proxyURL,
+		Headers:               headers,
+		MaxRequestsPerSecond:  maxRequestsPerSecond,
+		MaxConcurrentRequests: int(maxConcurrentRequests),
 	})
+	if err != nil {
+		resp.Diagnostics.AddError("Client Configuration Error",
+			fmt.Sprintf("Unable to create the Kibana client, got error: %s", err))
+		return
+	}
+
+	if runPreflight {
+		preflight(ctx, client, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	defaults, diags := newResourceDefaults(ctx, data.RuleDefaults, data.DefaultTags)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	resp.DataSourceData = client
-	resp.ResourceData = client
+	providerData := &providerData{
+		client:   client,
+		defaults: defaults,
+	}
+	resp.DataSourceData = providerData
+	resp.ResourceData = providerData
+}
+
+// stringValueOrEnv returns the configured value, or the value of the environment variable
+func stringValueOrEnv(value types.String, env string) string {
+	if !value.IsNull() {
+		return value.ValueString()
+	}
+	return os.Getenv(env)
+}
+
+// int64ValueOrEnv returns the configured value, or the value of the environment variable, or the default
+func int64ValueOrEnv(diags *diag.Diagnostics, value types.Int64, attribute, env string, defaultValue int64) int64 {
+	if !value.IsNull() {
+		return value.ValueInt64()
+	}
+	v := os.Getenv(env)
+	if v == "" {
+		return defaultValue
+	}
+	result, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		diags.AddAttributeError(path.Root(attribute), "Invalid Environment Variable",
+			fmt.Sprintf("Unable to parse %s, got error: %s", env, err))
+	}
+	return result
+}
+
+// float64ValueOrEnv returns the configured value, or the value of the environment variable, or the default
+func float64ValueOrEnv(diags *diag.Diagnostics, value types.Float64, attribute, env string, defaultValue float64) float64 {
+	if !value.IsNull() {
+		return value.ValueFloat64()
+	}
+	v := os.Getenv(env)
+	if v == "" {
+		return defaultValue
+	}
+	result, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		diags.AddAttributeError(path.Root(attribute), "Invalid Environment Variable",
+			fmt.Sprintf("Unable to parse %s, got error: %s", env, err))
+	}
+	return result
+}
+
+// boolValueOrEnv returns the configured value, or the value of the environment variable, or the default
+func boolValueOrEnv(diags *diag.Diagnostics, value types.Bool, attribute, env string, defaultValue bool) bool {
+	if !value.IsNull() {
+		return value.ValueBool()
+	}
+	v := os.Getenv(env)
+	if v == "" {
+		return defaultValue
+	}
+	result, err := strconv.ParseBool(v)
+	if err != nil {
+		diags.AddAttributeError(path.Root(attribute), "Invalid Environment Variable",
+			fmt.Sprintf("Unable to parse %s, got error: %s", env, err))
+	}
+	return result
+}
+
+// validateAuthentication ensures exactly one authentication method is configured
+func validateAuthentication(diags *diag.Diagnostics, username, password, apiKey, bearerToken, serviceToken string) {
+	var methods []string
+	if username != "" || password != "" {
+		if username == "" || password == "" {
+			diags.AddAttributeError(path.Root("user"), "Incomplete Basic Authentication",
+				"Both `user` and `password` must be set to use basic authentication.")
+			return
+		}
+		methods = append(methods, "user/password")
+	}
+	if apiKey != "" {
+		methods = append(methods, "api_key")
+	}
+	if bearerToken != "" {
+		methods = append(methods, "bearer_token")
+	}
+	if serviceToken != "" {
+		methods = append(methods, "service_token")
+	}
+
+	switch len(methods) {
+	case 0:
+		diags.AddError("Missing Authentication",
+			"One of `user`/`password`, `api_key`, `bearer_token` or `service_token` must be set.")
+	case 1:
+	default:
+		diags.AddError("Conflicting Authentication",
+			fmt.Sprintf("Only one authentication method can be used, got: %s.", strings.Join(methods, ", ")))
+	}
 }
 
 func (p *ElasticSiemProvider) Resources(ctx context.Context) []func() resource.Resource {
@@ -120,6 +587,8 @@ func (p *ElasticSiemProvider) Resources(ctx context.Context) []func() resource.R
 		NewDetectionRuleResource,
 		NewExceptionItemResource,
 		NewExceptionContainerResource,
+		NewKibanaApiObjectResource,
+		NewRuleResource,
 	}
 }
 
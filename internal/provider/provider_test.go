@@ -1,11 +1,18 @@
 package provider
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 )
 
 // testAccProtoV6ProviderFactories are used to instantiate a provider during
@@ -38,3 +45,223 @@ func testAccPreCheck(t *testing.T) {
 	// about the appropriate environment variables being set are common to see in a pre-check
 	// function.
 }
+
+func TestValidateAuthentication(t *testing.T) {
+	cases := []struct {
+		name                                                  string
+		username, password, apiKey, bearerToken, serviceToken string
+		wantError                                             bool
+	}{
+		{name: "basic", username: "elastic", password: "secret"},
+		{name: "api key", apiKey: "aWQ6a2V5"},
+		{name: "bearer token", bearerToken: "token"},
+		{name: "service token", serviceToken: "token"},
+		{name: "none", wantError: true},
+		{name: "user without password", username: "elastic", wantError: true},
+		{name: "basic and api key", username: "elastic", password: "secret", apiKey: "aWQ6a2V5", wantError: true},
+		{name: "bearer and service token", bearerToken: "token", serviceToken: "token", wantError: true},
+	}
+	for _, c := range cases {
+		var diags diag.Diagnostics
+		validateAuthentication(&diags, c.username, c.password, c.apiKey, c.bearerToken, c.serviceToken)
+		if diags.HasError() != c.wantError {
+			t.Errorf("%s: expected error %t, got diagnostics: %v", c.name, c.wantError, diags)
+		}
+	}
+}
+
+// configureProvider runs Configure with the given attributes, the other attributes are not set
+func configureProvider(t *testing.T, attributes map[string]tftypes.Value) (*providerData, diag.Diagnostics) {
+	ctx := context.Background()
+	p := New("test")()
+	var schemaResp provider.SchemaResponse
+	p.Schema(ctx, provider.SchemaRequest{}, &schemaResp)
+
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := make(map[string]tftypes.Value)
+	for name, attributeType := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(attributeType, nil)
+		if value, ok := attributes[name]; ok {
+			values[name] = value
+		}
+	}
+	// Fail fast when the test host is unreachable
+	if _, ok := attributes["max_retries"]; !ok {
+		values["max_retries"] = tftypes.NewValue(tftypes.Number, 0)
+	}
+
+	var resp provider.ConfigureResponse
+	p.Configure(ctx, provider.ConfigureRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)},
+	}, &resp)
+	data, _ := resp.ResourceData.(*providerData)
+	return data, resp.Diagnostics
+}
+
+func TestProviderConfigureEnvironment(t *testing.T) {
+	t.Setenv("ELASTIC_SIEM_HOSTNAME", "kibana.example")
+	t.Setenv("ELASTIC_SIEM_PORT", "5601")
+	t.Setenv("ELASTIC_SIEM_TLS", "false")
+	t.Setenv("ELASTIC_SIEM_PASSWORD", "secret")
+
+	// The password is taken from the environment while the user is configured
+	data, diags := configureProvider(t, map[string]tftypes.Value{
+		"user": tftypes.NewValue(tftypes.String, "elastic"),
+	})
+	if diags.HasError() {
+		t.Fatalf("expected the password to fall back to the environment, got: %v", diags)
+	}
+	if url := data.client.URL("/app/security"); url != "http://kibana.example:5601/app/security" {
+		t.Errorf("expected the endpoint of the environment, got %s", url)
+	}
+
+	// Credentials of another method in the environment conflict with the configured one
+	t.Setenv("ELASTIC_SIEM_USER", "elastic")
+	_, diags = configureProvider(t, map[string]tftypes.Value{
+		"api_key": tftypes.NewValue(tftypes.String, "aWQ6a2V5"),
+	})
+	if !hasErrorSummary(diags, "Conflicting Authentication") {
+		t.Errorf("expected mixed authentication methods to be rejected, got: %v", diags)
+	}
+
+	// Configured values take precedence over the environment
+	data, diags = configureProvider(t, map[string]tftypes.Value{
+		"password": tftypes.NewValue(tftypes.String, "configured"),
+		"port":     tftypes.NewValue(tftypes.Number, 443),
+		"tls":      tftypes.NewValue(tftypes.Bool, true),
+	})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if url := data.client.URL("/"); url != "https://kibana.example:443/" {
+		t.Errorf("expected the configured port and TLS setting, got %s", url)
+	}
+}
+
+func TestProviderConfigureEndpointPrecedence(t *testing.T) {
+	cloudID := "my-deployment:" + base64.StdEncoding.EncodeToString([]byte("found.io$es123$kb456"))
+	t.Setenv("ELASTIC_SIEM_USER", "elastic")
+	t.Setenv("ELASTIC_SIEM_PASSWORD", "secret")
+
+	cases := []struct {
+		name        string
+		env         map[string]string
+		attributes  map[string]tftypes.Value
+		wantURL     string
+		wantSummary string
+	}{
+		{
+			name:       "configured hostname over environment cloud id",
+			env:        map[string]string{"ELASTIC_SIEM_CLOUD_ID": cloudID},
+			attributes: map[string]tftypes.Value{"hostname": tftypes.NewValue(tftypes.String, "kibana.example")},
+			wantURL:    "https://kibana.example:443/",
+		},
+		{
+			name:       "configured cloud id over environment hostname",
+			env:        map[string]string{"ELASTIC_SIEM_HOSTNAME": "kibana.example"},
+			attributes: map[string]tftypes.Value{"cloud_id": tftypes.NewValue(tftypes.String, cloudID)},
+			wantURL:    "https://kb456.found.io:443/",
+		},
+		{
+			name:    "environment hostname over environment cloud id",
+			env:     map[string]string{"ELASTIC_SIEM_HOSTNAME": "kibana.example", "ELASTIC_SIEM_CLOUD_ID": cloudID},
+			wantURL: "https://kibana.example:443/",
+		},
+		{
+			name:    "environment cloud id",
+			env:     map[string]string{"ELASTIC_SIEM_CLOUD_ID": cloudID},
+			wantURL: "https://kb456.found.io:443/",
+		},
+		{
+			name:    "environment port and tls ignored with a cloud id",
+			env:     map[string]string{"ELASTIC_SIEM_CLOUD_ID": cloudID, "ELASTIC_SIEM_PORT": "5601", "ELASTIC_SIEM_TLS": "false"},
+			wantURL: "https://kb456.found.io:443/",
+		},
+		{
+			name: "configured port over cloud id",
+			env:  map[string]string{"ELASTIC_SIEM_CLOUD_ID": cloudID},
+			attributes: map[string]tftypes.Value{
+				"port": tftypes.NewValue(tftypes.Number, 9243),
+			},
+			wantURL: "https://kb456.found.io:9243/",
+		},
+		{
+			name: "configured hostname and cloud id",
+			attributes: map[string]tftypes.Value{
+				"hostname": tftypes.NewValue(tftypes.String, "kibana.example"),
+				"cloud_id": tftypes.NewValue(tftypes.String, cloudID),
+			},
+			wantSummary: "Conflicting Kibana Endpoint",
+		},
+		{
+			name:        "no endpoint",
+			wantSummary: "Missing Kibana Endpoint",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for _, env := range []string{"ELASTIC_SIEM_HOSTNAME", "ELASTIC_SIEM_CLOUD_ID", "ELASTIC_SIEM_ENDPOINTS", "ELASTIC_SIEM_PORT", "ELASTIC_SIEM_TLS"} {
+				t.Setenv(env, c.env[env])
+			}
+			data, diags := configureProvider(t, c.attributes)
+			if c.wantSummary != "" {
+				if !hasErrorSummary(diags, c.wantSummary) {
+					t.Errorf("expected the error %q, got: %v", c.wantSummary, diags)
+				}
+				return
+			}
+			if diags.HasError() {
+				t.Fatal(diags)
+			}
+			if url := data.client.URL("/"); url != c.wantURL {
+				t.Errorf("expected %s, got %s", c.wantURL, url)
+			}
+		})
+	}
+}
+
+func hasErrorSummary(diags diag.Diagnostics, summary string) bool {
+	for _, d := range diags.Errors() {
+		if d.Summary() == summary {
+			return true
+		}
+	}
+	return false
+}
+
+func TestProviderConfigureEnvironmentValidation(t *testing.T) {
+	t.Setenv("ELASTIC_SIEM_HOSTNAME", "kibana.example")
+	t.Setenv("ELASTIC_SIEM_USER", "elastic")
+	t.Setenv("ELASTIC_SIEM_PASSWORD", "secret")
+	t.Setenv("ELASTIC_SIEM_MAX_RETRIES", "-1")
+
+	_, diags := configureProvider(t, map[string]tftypes.Value{
+		"max_retries": tftypes.NewValue(tftypes.Number, nil),
+	})
+	if !hasErrorSummary(diags, "Invalid Environment Variable") {
+		t.Errorf("expected a negative ELASTIC_SIEM_MAX_RETRIES to be rejected, got: %v", diags)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	var diags diag.Diagnostics
+	configured := func(ctx context.Context, defaultTimeout time.Duration) (time.Duration, diag.Diagnostics) {
+		return time.Minute, nil
+	}
+	ctx, cancel := withTimeout(context.Background(), configured, &diags)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Minute || diags.HasError() {
+		t.Errorf("expected the configured timeout to bound the context, got %v and %v", deadline, diags)
+	}
+
+	invalid := func(ctx context.Context, defaultTimeout time.Duration) (time.Duration, diag.Diagnostics) {
+		var diags diag.Diagnostics
+		diags.AddError("Timeout Cannot Be Parsed", "invalid duration")
+		return defaultTimeout, diags
+	}
+	ctx, cancel = withTimeout(context.Background(), invalid, &diags)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok || !hasErrorSummary(diags, "Timeout Cannot Be Parsed") {
+		t.Errorf("expected the error to be reported and the default timeout to apply, got %v", diags)
+	}
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"terraform-provider-elastic-siem/internal/provider/transferobjects"
+)
+
+// ruleNotifyWhen are the moments a rule action runs, `onThrottleInterval` requires a throttle
+var ruleNotifyWhen = []string{"onActiveAlert", "onThrottleInterval", "onActionGroupChange"}
+
+// Rule level throttle values of rules created before actions had their own frequency
+const (
+	legacyThrottleNoActions = "no_actions"
+	legacyThrottleRule      = "rule"
+)
+
+// migrateRuleThrottle moves the deprecated rule level `throttle` into the frequency of the actions that do
+// not set one, Kibana rejects a rule with both. `no_actions` mutes the actions and is kept as it is.
+func migrateRuleThrottle(rule *transferobjects.DetectionRule) {
+	if rule.Throttle == "" || rule.Throttle == legacyThrottleNoActions || len(rule.Actions) == 0 {
+		return
+	}
+	for i := range rule.Actions {
+		if rule.Actions[i].Frequency == nil {
+			rule.Actions[i].Frequency = legacyThrottleFrequency(rule.Throttle)
+		}
+	}
+	rule.Throttle = ""
+}
+
+// legacyThrottleFrequency returns the action frequency equivalent to a rule level throttle, which is either
+// `rule` for every rule run or an interval like `1h`. Both send a summary of the alerts.
+func legacyThrottleFrequency(throttle string) *transferobjects.ActionFrequency {
+	if throttle == legacyThrottleRule {
+		return &transferobjects.ActionFrequency{Summary: true, NotifyWhen: "onActiveAlert"}
+	}
+	return &transferobjects.ActionFrequency{Summary: true, NotifyWhen: "onThrottleInterval", Throttle: &throttle}
+}
+
+// isDefaultActionFrequency reports whether the frequency is the one Kibana gives an action without frequency
+func isDefaultActionFrequency(frequency *transferobjects.ActionFrequency) bool {
+	return frequency.Summary && frequency.NotifyWhen == "onActiveAlert" && frequency.Throttle == nil
+}
+
+// normalizeRuleActions drops the default frequency Kibana adds to the actions and fills in the values Kibana
+// requires but returns empty, so the actions sent and the actions read back compare equal
+func normalizeRuleActions(rule *transferobjects.DetectionRule) {
+	for i := range rule.Actions {
+		if frequency := rule.Actions[i].Frequency; frequency != nil && isDefaultActionFrequency(frequency) {
+			rule.Actions[i].Frequency = nil
+		}
+		filter := rule.Actions[i].AlertsFilter
+		if filter != nil && filter.Query != nil && filter.Query.Filters == nil {
+			filter.Query.Filters = []interface{}{}
+		}
+	}
+}
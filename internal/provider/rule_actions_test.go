@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"encoding/json"
+	"terraform-provider-elastic-siem/internal/provider/transferobjects"
+	"testing"
+)
+
+func TestMigrateRuleThrottle(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{
+			name:     "interval",
+			content:  `{"throttle":"1h","actions":[{"id":"a","params":{"message":"m"}},{"id":"b","frequency":{"summary":false,"notifyWhen":"onThrottleInterval","throttle":"1d"}}]}`,
+			expected: `[{"id":"a","params":{"message":"m"},"frequency":{"summary":true,"notifyWhen":"onThrottleInterval","throttle":"1h"}},{"id":"b","frequency":{"summary":false,"notifyWhen":"onThrottleInterval","throttle":"1d"}}]`,
+		},
+		{
+			name:     "every rule run",
+			content:  `{"throttle":"rule","actions":[{"id":"a"}]}`,
+			expected: `[{"id":"a","frequency":{"summary":true,"notifyWhen":"onActiveAlert","throttle":null}}]`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var rule transferobjects.DetectionRule
+			if err := json.Unmarshal([]byte(test.content), &rule); err != nil {
+				t.Fatal(err)
+			}
+			migrateRuleThrottle(&rule)
+			actions, _ := json.Marshal(rule.Actions)
+			if string(actions) != test.expected || rule.Throttle != "" {
+				t.Errorf("unexpected migration, throttle %q and actions %s", rule.Throttle, actions)
+			}
+		})
+	}
+
+	rule := transferobjects.DetectionRule{Throttle: "no_actions", Actions: []transferobjects.ActionItem{{ID: "a"}}}
+	migrateRuleThrottle(&rule)
+	if rule.Throttle != "no_actions" || rule.Actions[0].Frequency != nil {
+		t.Errorf("expected muted actions to be kept, got %+v", rule)
+	}
+}
+
+func TestMergeRuleContentActions(t *testing.T) {
+	content := `{"type":"query","throttle":"rule","actions":[{"id":"a","action_type_id":".webhook","params":{"body":"{}"},` +
+		`"alerts_filter":{"query":{"kql":"x"}}}]}`
+	_, _, effective, err := mergeRuleContent(content, resourceDefaults{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"actions":[{"id":"a","params":{"body":"{}"},"action_type_id":".webhook","alerts_filter":{"query":{"kql":"x","filters":[]}}}],"type":"query"}`
+	if effective != expected {
+		t.Errorf("unexpected effective rule content: %s", effective)
+	}
+}
@@ -0,0 +1,135 @@
+package provider
+
+import (
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"sort"
+	"strings"
+	"terraform-provider-elastic-siem/internal/helpers"
+)
+
+// ruleTypeSpec lists the keys of the rule content a rule type requires and the other type specific keys
+// it accepts, together with the query languages of the type
+type ruleTypeSpec struct {
+	required  []string
+	allowed   []string
+	languages []string
+}
+
+// ruleCommonRequiredKeys are required by every rule type
+var ruleCommonRequiredKeys = []string{"name", "description", "risk_score", "severity"}
+
+var ruleTypeSpecs = map[string]ruleTypeSpec{
+	"query": {
+		allowed:   []string{"query", "language", "index", "data_view_id", "filters", "saved_id"},
+		languages: []string{"kuery", "lucene"},
+	},
+	"saved_query": {
+		required:  []string{"saved_id"},
+		allowed:   []string{"query", "language", "index", "data_view_id", "filters"},
+		languages: []string{"kuery", "lucene"},
+	},
+	"eql": {
+		required:  []string{"query"},
+		allowed:   []string{"language", "index", "data_view_id", "filters", "event_category_field", "timestamp_field", "tiebreaker_field"},
+		languages: []string{"eql"},
+	},
+	"esql": {
+		required:  []string{"query", "language"},
+		languages: []string{"esql"},
+	},
+	"threshold": {
+		required:  []string{"query", "threshold", "threshold/value"},
+		allowed:   []string{"language", "index", "data_view_id", "filters", "saved_id"},
+		languages: []string{"kuery", "lucene"},
+	},
+	"threat_match": {
+		required: []string{"query", "threat_index", "threat_query", "threat_mapping"},
+		allowed: []string{"language", "index", "data_view_id", "filters", "saved_id", "threat_filters",
+			"threat_indicator_path", "threat_language"},
+		languages: []string{"kuery", "lucene"},
+	},
+	"machine_learning": {
+		required: []string{"anomaly_threshold", "machine_learning_job_id"},
+	},
+	"new_terms": {
+		required:  []string{"query", "new_terms_fields", "history_window_start"},
+		allowed:   []string{"language", "index", "data_view_id", "filters"},
+		languages: []string{"kuery", "lucene"},
+	},
+}
+
+// ruleTypeSpecificKeys are the keys some rule types accept and others reject
+var ruleTypeSpecificKeys = func() []string {
+	var keys []string
+	for _, spec := range ruleTypeSpecs {
+		for _, key := range append(append([]string{}, spec.required...), spec.allowed...) {
+			if !strings.Contains(key, "/") && !helpers.Contains(keys, key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}()
+
+// validateRuleContent reports the keys of the rule content Kibana would reject for the type of the rule.
+// Keys added by the provider `rule_defaults` are not validated, the defaults respect the rule type.
+func validateRuleContent(content map[string]interface{}, attribute path.Path, diags *diag.Diagnostics) {
+	addError := func(key, detail string) {
+		diags.AddAttributeError(attribute, "Invalid Rule Content", fmt.Sprintf("`%s`: %s", key, detail))
+	}
+
+	for _, key := range ruleCommonRequiredKeys {
+		if isMissingRuleKey(content, key) {
+			addError(key, "the key is required by every rule type.")
+		}
+	}
+
+	ruleType, _ := content["type"].(string)
+	spec, known := ruleTypeSpecs[ruleType]
+	if !known {
+		addError("type", fmt.Sprintf("expected one of %s, got %q.", strings.Join(ruleTypes, ", "), ruleType))
+		return
+	}
+
+	for _, key := range spec.required {
+		if parent, _, nested := strings.Cut(key, "/"); nested && isMissingRuleKey(content, parent) {
+			// The missing parent is already reported
+			continue
+		}
+		if isMissingRuleKey(content, key) {
+			addError(strings.ReplaceAll(key, "/", "."), fmt.Sprintf("the key is required by `%s` rules.", ruleType))
+		}
+	}
+	for _, key := range ruleTypeSpecificKeys {
+		// Empty values are accepted, e.g. the empty `threshold` of content encoded from a rule struct
+		if !isMissingRuleKey(content, key) && !helpers.Contains(spec.required, key) && !helpers.Contains(spec.allowed, key) {
+			addError(key, fmt.Sprintf("the key is not supported by `%s` rules.", ruleType))
+		}
+	}
+	if language, ok := content["language"].(string); ok && len(spec.languages) > 0 && !helpers.Contains(spec.languages, language) {
+		addError("language", fmt.Sprintf("`%s` rules support the languages %s, got %q.",
+			ruleType, strings.Join(spec.languages, ", "), language))
+	}
+}
+
+// isMissingRuleKey reports whether the key at the slash separated path is absent, null or empty
+func isMissingRuleKey(content map[string]interface{}, key string) bool {
+	value, ok := helpers.GetJSONPath(content, key)
+	if !ok {
+		return true
+	}
+	switch typed := value.(type) {
+	case nil:
+		return true
+	case string:
+		return typed == ""
+	case []interface{}:
+		return len(typed) == 0
+	case map[string]interface{}:
+		return len(typed) == 0
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func TestValidateRuleContent(t *testing.T) {
+	common := `"name":"My rule","description":"My rule","risk_score":21,"severity":"low"`
+	tests := []struct {
+		name    string
+		content string
+		errors  []string
+	}{
+		{"query", `{` + common + `,"type":"query","query":"*:*","index":["logs-*"]}`, nil},
+		{"empty keys of other types", `{` + common + `,"type":"query","threshold":{},"threat_mapping":[]}`, nil},
+		{"missing common keys", `{"type":"query"}`, []string{"`name`", "`description`", "`risk_score`", "`severity`"}},
+		{"unknown type", `{` + common + `,"type":"detection"}`, []string{"`type`: expected one of"}},
+		{"threshold without value", `{` + common + `,"type":"threshold","query":"*:*","threshold":{"field":["user.name"]}}`,
+			[]string{"`threshold.value`: the key is required by `threshold` rules."}},
+		{"threshold without threshold", `{` + common + `,"type":"threshold","query":"*:*"}`,
+			[]string{"`threshold`: the key is required by `threshold` rules."}},
+		{"threat match", `{` + common + `,"type":"threat_match","query":"*:*","threat_index":[],"threat_query":"*:*"}`,
+			[]string{"`threat_index`: the key is required", "`threat_mapping`: the key is required"}},
+		{"eql with kuery", `{` + common + `,"type":"eql","query":"any where true","language":"kuery"}`,
+			[]string{"`language`: `eql` rules support the languages eql, got \"kuery\"."}},
+		{"esql with index", `{` + common + `,"type":"esql","query":"from logs-*","language":"esql","index":["logs-*"]}`,
+			[]string{"`index`: the key is not supported by `esql` rules."}},
+		{"machine learning", `{` + common + `,"type":"machine_learning","anomaly_threshold":50,"machine_learning_job_id":["job"],"query":"*:*"}`,
+			[]string{"`query`: the key is not supported by `machine_learning` rules."}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var content map[string]interface{}
+			if err := json.Unmarshal([]byte(test.content), &content); err != nil {
+				t.Fatal(err)
+			}
+			var diags diag.Diagnostics
+			validateRuleContent(content, path.Root("rule_content"), &diags)
+			if len(diags) != len(test.errors) {
+				t.Fatalf("expected %d errors, got %v", len(test.errors), diags)
+			}
+			for i, expected := range test.errors {
+				if !strings.Contains(diags[i].Detail(), expected) {
+					t.Errorf("expected error %q, got %q", expected, diags[i].Detail())
+				}
+			}
+		})
+	}
+}
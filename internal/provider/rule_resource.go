@@ -0,0 +1,922 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"terraform-provider-elastic-siem/internal/helpers"
+	"terraform-provider-elastic-siem/internal/provider/transferobjects"
+
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ resource.Resource = &RuleResource{}
+var _ resource.ResourceWithImportState = &RuleResource{}
+var _ resource.ResourceWithModifyPlan = &RuleResource{}
+
+// ruleTypes are the detection rule types Kibana supports
+var ruleTypes = []string{"eql", "esql", "machine_learning", "new_terms", "query", "saved_query", "threat_match", "threshold"}
+
+var ruleSeverities = []string{"low", "medium", "high", "critical"}
+
+// Kibana stores these values for a rule that does not set them
+const (
+	defaultRuleInterval            = "5m"
+	defaultRuleFrom                = "now-6m"
+	defaultRuleTo                  = "now"
+	defaultRuleMaxSignals          = 100
+	defaultRuleThreatIndicatorPath = "threat.indicator"
+)
+
+func NewRuleResource() resource.Resource {
+	return &RuleResource{}
+}
+
+// RuleResource defines the resource implementation of a detection rule with a typed schema
+type RuleResource struct {
+	client   *helpers.Client
+	defaults resourceDefaults
+}
+
+func (r *RuleResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_rule"
+}
+
+func (r *RuleResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Detection rule resource with a typed schema, an alternative to `elastic-siem_detection_rule` and its JSON encoded `rule_content`",
+
+		Attributes: map[string]schema.Attribute{
+			"rule_id": schema.StringAttribute{
+				MarkdownDescription: "The stable identifier of the rule, generated by Kibana when not set",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "The name of the rule",
+				Required:            true,
+				Validators:          []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"description": schema.StringAttribute{
+				MarkdownDescription: "The description of the rule",
+				Required:            true,
+				Validators:          []validator.String{stringvalidator.LengthAtLeast(1)},
+			},
+			"type": schema.StringAttribute{
+				MarkdownDescription: "The type of the rule, one of `eql`, `esql`, `machine_learning`, `new_terms`, `query`, `saved_query`, `threat_match` or `threshold`",
+				Required:            true,
+				Validators:          []validator.String{stringvalidator.OneOf(ruleTypes...)},
+			},
+			"enabled": schema.BoolAttribute{
+				MarkdownDescription: "Whether the rule runs (defaults to `true`)",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(true),
+			},
+			"risk_score": schema.Int64Attribute{
+				MarkdownDescription: "The risk score of the alerts, from 0 to 100",
+				Required:            true,
+				Validators:          []validator.Int64{int64validator.Between(0, 100)},
+			},
+			"severity": schema.StringAttribute{
+				MarkdownDescription: "The severity of the alerts, one of `low`, `medium`, `high` or `critical`",
+				Required:            true,
+				Validators:          []validator.String{stringvalidator.OneOf(ruleSeverities...)},
+			},
+			"query": schema.StringAttribute{
+				MarkdownDescription: "The query of the rule",
+				Optional:            true,
+			},
+			"language": schema.StringAttribute{
+				MarkdownDescription: "The language of the query, one of `kuery`, `lucene`, `eql` or `esql` (defaults to the language of the rule type)",
+				Optional:            true,
+				Computed:            true,
+				Validators:          []validator.String{stringvalidator.OneOf("kuery", "lucene", "eql", "esql")},
+			},
+			"index": schema.ListAttribute{
+				MarkdownDescription: "The index patterns searched by the rule (defaults to the provider `rule_defaults`)",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Computed:            true,
+			},
+			"filters": schema.StringAttribute{
+				MarkdownDescription: "The query filters of the rule (JSON encoded array)",
+				Optional:            true,
+				Validators:          []validator.String{jsonArrayValidator{}},
+			},
+			"saved_id": schema.StringAttribute{
+				MarkdownDescription: "The saved query of a `saved_query` rule",
+				Optional:            true,
+			},
+			"interval": schema.StringAttribute{
+				MarkdownDescription: "How often the rule runs, e.g. `5m` (defaults to the provider `rule_defaults` or `5m`)",
+				Optional:            true,
+				Computed:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(regexp.MustCompile(`^[1-9][0-9]*[smhd]$`), "must be a duration such as `5m`"),
+				},
+			},
+			"from": schema.StringAttribute{
+				MarkdownDescription: "The start of the time range searched, e.g. `now-6m` (defaults to the provider `rule_defaults` or `now-6m`)",
+				Optional:            true,
+				Computed:            true,
+			},
+			"to": schema.StringAttribute{
+				MarkdownDescription: "The end of the time range searched (defaults to `now`)",
+				Optional:            true,
+				Computed:            true,
+			},
+			"max_signals": schema.Int64Attribute{
+				MarkdownDescription: "The maximum number of alerts created per run (defaults to the provider `rule_defaults` or `100`)",
+				Optional:            true,
+				Computed:            true,
+				Validators:          []validator.Int64{int64validator.AtLeast(1)},
+			},
+			"author": schema.ListAttribute{
+				MarkdownDescription: "The authors of the rule (defaults to the provider `rule_defaults`)",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Computed:            true,
+			},
+			"license": schema.StringAttribute{
+				MarkdownDescription: "The license of the rule (defaults to the provider `rule_defaults`)",
+				Optional:            true,
+				Computed:            true,
+			},
+			"tags": schema.ListAttribute{
+				MarkdownDescription: "The tags of the rule",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"tags_all": schema.ListAttribute{
+				MarkdownDescription: "The tags of the rule merged with the provider `rule_defaults` and `default_tags`",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"references": schema.ListAttribute{
+				MarkdownDescription: "References to information about the threat detected by the rule",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"false_positives": schema.ListAttribute{
+				MarkdownDescription: "Common reasons of false positive alerts",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"note": schema.StringAttribute{
+				MarkdownDescription: "The investigation guide of the rule (Markdown)",
+				Optional:            true,
+			},
+			"setup": schema.StringAttribute{
+				MarkdownDescription: "The setup guide of the rule (Markdown)",
+				Optional:            true,
+			},
+			"building_block_type": schema.StringAttribute{
+				MarkdownDescription: "Set to `default` to hide the alerts of the rule by default",
+				Optional:            true,
+				Validators:          []validator.String{stringvalidator.OneOf("default")},
+			},
+			"rule_name_override": schema.StringAttribute{
+				MarkdownDescription: "The source event field used as the name of the alerts",
+				Optional:            true,
+			},
+			"timestamp_override": schema.StringAttribute{
+				MarkdownDescription: "The source event field used as the timestamp of the rule queries",
+				Optional:            true,
+			},
+			"event_category_field": schema.StringAttribute{
+				MarkdownDescription: "The event category field of an `eql` rule",
+				Optional:            true,
+			},
+			"timestamp_field": schema.StringAttribute{
+				MarkdownDescription: "The timestamp field of an `eql` rule",
+				Optional:            true,
+			},
+			"tiebreaker_field": schema.StringAttribute{
+				MarkdownDescription: "The field sorting events with the same timestamp of an `eql` rule",
+				Optional:            true,
+			},
+			"anomaly_threshold": schema.Int64Attribute{
+				MarkdownDescription: "The anomaly score threshold of a `machine_learning` rule",
+				Optional:            true,
+				Validators:          []validator.Int64{int64validator.Between(0, 100)},
+			},
+			"machine_learning_job_id": schema.ListAttribute{
+				MarkdownDescription: "The machine learning jobs of a `machine_learning` rule",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"new_terms_fields": schema.ListAttribute{
+				MarkdownDescription: "The fields whose new values are detected by a `new_terms` rule",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators:          []validator.List{listvalidator.SizeBetween(1, 3)},
+			},
+			"history_window_start": schema.StringAttribute{
+				MarkdownDescription: "The start of the history searched by a `new_terms` rule, e.g. `now-7d`",
+				Optional:            true,
+			},
+			"threat_index": schema.ListAttribute{
+				MarkdownDescription: "The indicator index patterns of a `threat_match` rule",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"threat_query": schema.StringAttribute{
+				MarkdownDescription: "The query selecting the indicators of a `threat_match` rule",
+				Optional:            true,
+			},
+			"threat_indicator_path": schema.StringAttribute{
+				MarkdownDescription: "The path of the indicator in the indicator documents of a `threat_match` rule (defaults to `threat.indicator`)",
+				Optional:            true,
+				Computed:            true,
+			},
+			"threat_filters": schema.StringAttribute{
+				MarkdownDescription: "The filters of the indicator query of a `threat_match` rule (JSON encoded array)",
+				Optional:            true,
+				Validators:          []validator.String{jsonArrayValidator{}},
+			},
+			"space_id": spaceIdAttribute(),
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Rule identifier (in UUID format)",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"threat": schema.ListNestedBlock{
+				MarkdownDescription: "A MITRE ATT&CK tactic detected by the rule",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"framework": schema.StringAttribute{
+							MarkdownDescription: "The threat framework (defaults to `MITRE ATT&CK`)",
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString("MITRE ATT&CK"),
+						},
+					},
+					Blocks: map[string]schema.Block{
+						"tactic": schema.SingleNestedBlock{
+							MarkdownDescription: "The tactic",
+							Attributes:          threatEntryAttributes(false),
+							Validators: []validator.Object{
+								objectvalidator.IsRequired(),
+								objectvalidator.AlsoRequires(path.MatchRelative().AtName("id"), path.MatchRelative().AtName("name"),
+									path.MatchRelative().AtName("reference")),
+							},
+						},
+						"technique": schema.ListNestedBlock{
+							MarkdownDescription: "A technique of the tactic",
+							NestedObject: schema.NestedBlockObject{
+								Attributes: threatEntryAttributes(true),
+								Blocks: map[string]schema.Block{
+									"subtechnique": schema.ListNestedBlock{
+										MarkdownDescription: "A subtechnique of the technique",
+										NestedObject: schema.NestedBlockObject{
+											Attributes: threatEntryAttributes(true),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"threshold": schema.SingleNestedBlock{
+				MarkdownDescription: "The threshold of a `threshold` rule",
+				Attributes: map[string]schema.Attribute{
+					"field": schema.ListAttribute{
+						MarkdownDescription: "The fields the events are grouped by",
+						ElementType:         types.StringType,
+						Optional:            true,
+						Validators:          []validator.List{listvalidator.SizeAtMost(5)},
+					},
+					"value": schema.Int64Attribute{
+						MarkdownDescription: "The number of events of a group that creates an alert, required",
+						Optional:            true,
+						Validators:          []validator.Int64{int64validator.AtLeast(1)},
+					},
+				},
+				Blocks: map[string]schema.Block{
+					"cardinality": schema.ListNestedBlock{
+						MarkdownDescription: "The minimum number of unique values of a field in a group",
+						NestedObject: schema.NestedBlockObject{
+							Attributes: map[string]schema.Attribute{
+								"field": schema.StringAttribute{
+									MarkdownDescription: "The field whose unique values are counted",
+									Required:            true,
+								},
+								"value": schema.Int64Attribute{
+									MarkdownDescription: "The minimum number of unique values",
+									Required:            true,
+									Validators:          []validator.Int64{int64validator.AtLeast(1)},
+								},
+							},
+						},
+						Validators: []validator.List{listvalidator.SizeAtMost(1)},
+					},
+				},
+				Validators: []validator.Object{objectvalidator.AlsoRequires(path.MatchRelative().AtName("value"))},
+			},
+			"threat_mapping": schema.ListNestedBlock{
+				MarkdownDescription: "Matches source events with indicators of a `threat_match` rule, all entries of a mapping must match",
+				NestedObject: schema.NestedBlockObject{
+					Blocks: map[string]schema.Block{
+						"entries": schema.ListNestedBlock{
+							MarkdownDescription: "A field of the source event matching a field of the indicator",
+							NestedObject: schema.NestedBlockObject{
+								Attributes: map[string]schema.Attribute{
+									"field": schema.StringAttribute{
+										MarkdownDescription: "The field of the source event",
+										Required:            true,
+									},
+									"type": schema.StringAttribute{
+										MarkdownDescription: "The type of the entry (defaults to `mapping`)",
+										Optional:            true,
+										Computed:            true,
+										Default:             stringdefault.StaticString("mapping"),
+										Validators:          []validator.String{stringvalidator.OneOf("mapping")},
+									},
+									"value": schema.StringAttribute{
+										MarkdownDescription: "The field of the indicator",
+										Required:            true,
+									},
+								},
+							},
+							Validators: []validator.List{listvalidator.SizeAtLeast(1)},
+						},
+					},
+				},
+			},
+			"risk_score_mapping": schema.ListNestedBlock{
+				MarkdownDescription: "Overrides the risk score with the value of a source event field",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"field": schema.StringAttribute{
+							MarkdownDescription: "The source event field",
+							Required:            true,
+						},
+						"operator": schema.StringAttribute{
+							MarkdownDescription: "The operator (defaults to `equals`)",
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString("equals"),
+							Validators:          []validator.String{stringvalidator.OneOf("equals")},
+						},
+						"value": schema.StringAttribute{
+							MarkdownDescription: "The value (defaults to an empty string)",
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString(""),
+						},
+					},
+				},
+			},
+			"severity_mapping": schema.ListNestedBlock{
+				MarkdownDescription: "Overrides the severity when a source event field has a value",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"field": schema.StringAttribute{
+							MarkdownDescription: "The source event field",
+							Required:            true,
+						},
+						"operator": schema.StringAttribute{
+							MarkdownDescription: "The operator (defaults to `equals`)",
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString("equals"),
+							Validators:          []validator.String{stringvalidator.OneOf("equals")},
+						},
+						"value": schema.StringAttribute{
+							MarkdownDescription: "The value of the field",
+							Required:            true,
+						},
+						"severity": schema.StringAttribute{
+							MarkdownDescription: "The severity of the alert, one of `low`, `medium`, `high` or `critical`",
+							Required:            true,
+							Validators:          []validator.String{stringvalidator.OneOf(ruleSeverities...)},
+						},
+					},
+				},
+			},
+			"actions": schema.ListNestedBlock{
+				MarkdownDescription: "A notification sent through a connector when the rule creates alerts",
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"group": schema.StringAttribute{
+							MarkdownDescription: "The action group (defaults to `default`)",
+							Optional:            true,
+							Computed:            true,
+							Default:             stringdefault.StaticString("default"),
+						},
+						"id": schema.StringAttribute{
+							MarkdownDescription: "The ID of the connector",
+							Required:            true,
+						},
+						"action_type_id": schema.StringAttribute{
+							MarkdownDescription: "The type of the connector, e.g. `.email`",
+							Required:            true,
+						},
+						"params": schema.StringAttribute{
+							MarkdownDescription: "The parameters of the connector (JSON encoded object), e.g. the `message` of a Slack or Teams connector or the `subActionParams` of a Jira connector",
+							Optional:            true,
+							Validators:          []validator.String{jsonObjectValidator{}},
+						},
+					},
+					Blocks: map[string]schema.Block{
+						"frequency": schema.SingleNestedBlock{
+							MarkdownDescription: "When the action runs, for every rule run when not set",
+							Attributes: map[string]schema.Attribute{
+								"summary": schema.BoolAttribute{
+									MarkdownDescription: "Whether a summary of the alerts is sent instead of one notification per alert (defaults to `true`)",
+									Optional:            true,
+									Computed:            true,
+									Default:             booldefault.StaticBool(true),
+								},
+								"notify_when": schema.StringAttribute{
+									MarkdownDescription: fmt.Sprintf("When the action runs, one of `%s`, required", strings.Join(ruleNotifyWhen, "`, `")),
+									Optional:            true,
+									Validators:          []validator.String{stringvalidator.OneOf(ruleNotifyWhen...)},
+								},
+								"throttle": schema.StringAttribute{
+									MarkdownDescription: "How often the action runs at most, e.g. `1h`, required by `onThrottleInterval`",
+									Optional:            true,
+								},
+							},
+							Validators: []validator.Object{objectvalidator.AlsoRequires(path.MatchRelative().AtName("notify_when"))},
+						},
+						"alerts_filter": schema.SingleNestedBlock{
+							MarkdownDescription: "Limits the alerts the action runs for",
+							Blocks: map[string]schema.Block{
+								"query": schema.SingleNestedBlock{
+									MarkdownDescription: "Runs the action for the alerts matching a query",
+									Attributes: map[string]schema.Attribute{
+										"kql": schema.StringAttribute{
+											MarkdownDescription: "The KQL query the alerts match, required",
+											Optional:            true,
+										},
+										"filters": schema.StringAttribute{
+											MarkdownDescription: "The filters the alerts match (JSON encoded array)",
+											Optional:            true,
+											Validators:          []validator.String{jsonArrayValidator{}},
+										},
+									},
+									Validators: []validator.Object{objectvalidator.AlsoRequires(path.MatchRelative().AtName("kql"))},
+								},
+								"timeframe": schema.SingleNestedBlock{
+									MarkdownDescription: "Runs the action for the alerts created during a timeframe",
+									Attributes: map[string]schema.Attribute{
+										"days": schema.ListAttribute{
+											MarkdownDescription: "The days of the week, from `1` for Monday to `7` for Sunday, required",
+											ElementType:         types.Int64Type,
+											Optional:            true,
+											Validators: []validator.List{
+												listvalidator.SizeAtLeast(1),
+												listvalidator.ValueInt64sAre(int64validator.Between(1, 7)),
+											},
+										},
+										"timezone": schema.StringAttribute{
+											MarkdownDescription: "The timezone of the hours, e.g. `Europe/Berlin`, required",
+											Optional:            true,
+										},
+										"hours_start": schema.StringAttribute{
+											MarkdownDescription: "The start of the timeframe in `HH:mm` format, required",
+											Optional:            true,
+										},
+										"hours_end": schema.StringAttribute{
+											MarkdownDescription: "The end of the timeframe in `HH:mm` format, required",
+											Optional:            true,
+										},
+									},
+									Validators: []validator.Object{objectvalidator.AlsoRequires(
+										path.MatchRelative().AtName("days"),
+										path.MatchRelative().AtName("timezone"),
+										path.MatchRelative().AtName("hours_start"),
+										path.MatchRelative().AtName("hours_end"),
+									)},
+								},
+							},
+						},
+					},
+				},
+			},
+			"exceptions_list": exceptionListBlock(),
+			"alert_suppression": schema.SingleNestedBlock{
+				MarkdownDescription: "Suppresses alerts with the same values of fields",
+				Attributes: map[string]schema.Attribute{
+					"group_by": schema.ListAttribute{
+						MarkdownDescription: "The fields the alerts are suppressed by, required",
+						ElementType:         types.StringType,
+						Optional:            true,
+						Validators:          []validator.List{listvalidator.SizeBetween(1, 3)},
+					},
+					"missing_fields_strategy": schema.StringAttribute{
+						MarkdownDescription: "How alerts missing the fields are suppressed, `suppress` or `doNotSuppress` (defaults to `suppress`)",
+						Optional:            true,
+						Computed:            true,
+						Default:             stringdefault.StaticString("suppress"),
+						Validators:          []validator.String{stringvalidator.OneOf("suppress", "doNotSuppress")},
+					},
+				},
+				Blocks: map[string]schema.Block{
+					"duration": schema.SingleNestedBlock{
+						MarkdownDescription: "How long alerts are suppressed, for every rule run when not set",
+						Attributes: map[string]schema.Attribute{
+							"value": schema.Int64Attribute{
+								MarkdownDescription: "The duration, required",
+								Optional:            true,
+								Validators:          []validator.Int64{int64validator.AtLeast(1)},
+							},
+							"unit": schema.StringAttribute{
+								MarkdownDescription: "The unit of the duration, `s`, `m` or `h`, required",
+								Optional:            true,
+								Validators:          []validator.String{stringvalidator.OneOf("s", "m", "h")},
+							},
+						},
+						Validators: []validator.Object{
+							objectvalidator.AlsoRequires(path.MatchRelative().AtName("value"), path.MatchRelative().AtName("unit")),
+						},
+					},
+				},
+				Validators: []validator.Object{objectvalidator.AlsoRequires(path.MatchRelative().AtName("group_by"))},
+			},
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
+	}
+}
+
+// threatEntryAttributes returns the attributes of a MITRE ATT&CK tactic, technique or subtechnique. The
+// attributes of a single nested block are optional, the framework would require them when the block is absent.
+func threatEntryAttributes(required bool) map[string]schema.Attribute {
+	return map[string]schema.Attribute{
+		"id": schema.StringAttribute{
+			MarkdownDescription: "The ID, e.g. `TA0002`",
+			Required:            required,
+			Optional:            !required,
+		},
+		"name": schema.StringAttribute{
+			MarkdownDescription: "The name",
+			Required:            required,
+			Optional:            !required,
+		},
+		"reference": schema.StringAttribute{
+			MarkdownDescription: "The URL of the description",
+			Required:            required,
+			Optional:            !required,
+		},
+	}
+}
+
+func (r *RuleResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	data, ok := req.ProviderData.(*providerData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *provider.providerData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = data.client
+	r.defaults = data.defaults
+}
+
+func (r *RuleResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to plan on destroy
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var config, plan *RuleResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	r.planDefaults(ctx, config, plan, &resp.Diagnostics)
+	planExceptionListIds(ctx, r.client, plan.SpaceId.ValueString(), config.ExceptionsList, plan.ExceptionsList, &resp.Diagnostics)
+	if r.client != nil {
+		// The IDs of exception containers created in the same apply are not known yet, they do not
+		// matter for the capabilities of the rule
+		rule, _ := plan.toDetectionRule(ctx, &resp.Diagnostics)
+		checkRuleCapabilities(ctx, r.client, rule, path.Root("type"), &resp.Diagnostics)
+	}
+
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+// planDefaults sets the attributes the configuration does not set to the provider `rule_defaults`, or to
+// the value Kibana stores, so the plan shows the rule that is created
+func (r *RuleResource) planDefaults(ctx context.Context, config, plan *RuleResourceModel, diags *diag.Diagnostics) {
+	defaults := r.defaults.rule
+	if config.Interval.IsNull() {
+		plan.Interval = types.StringValue(defaultString(defaults.interval, defaultRuleInterval))
+	}
+	if config.From.IsNull() {
+		plan.From = types.StringValue(defaultString(defaults.from, defaultRuleFrom))
+	}
+	if config.To.IsNull() {
+		plan.To = types.StringValue(defaultRuleTo)
+	}
+	if config.MaxSignals.IsNull() {
+		maxSignals := defaults.maxSignals
+		if maxSignals == 0 {
+			maxSignals = defaultRuleMaxSignals
+		}
+		plan.MaxSignals = types.Int64Value(int64(maxSignals))
+	}
+	if config.Author.IsNull() {
+		plan.Author = stringListValue(ctx, types.ListNull(types.StringType), defaults.author, diags)
+	}
+	if config.License.IsNull() {
+		plan.License = stringValue(types.StringNull(), defaults.license)
+	}
+
+	ruleType := plan.Type.ValueString()
+	if config.Index.IsNull() {
+		plan.Index = types.ListNull(types.StringType)
+		if plan.Type.IsUnknown() {
+			plan.Index = types.ListUnknown(types.StringType)
+		} else if ruleType != "esql" && ruleType != "machine_learning" {
+			plan.Index = stringListValue(ctx, plan.Index, defaults.index, diags)
+		}
+	}
+	if config.Language.IsNull() {
+		plan.Language = stringValue(types.StringNull(), defaultRuleLanguage(ruleType))
+		if plan.Type.IsUnknown() {
+			plan.Language = types.StringUnknown()
+		}
+	}
+	if config.ThreatIndicatorPath.IsNull() {
+		plan.ThreatIndicatorPath = types.StringNull()
+		if plan.Type.IsUnknown() {
+			plan.ThreatIndicatorPath = types.StringUnknown()
+		} else if ruleType == "threat_match" {
+			plan.ThreatIndicatorPath = types.StringValue(defaultRuleThreatIndicatorPath)
+		}
+	}
+
+	if plan.Tags.IsUnknown() {
+		plan.TagsAll = types.ListUnknown(types.StringType)
+	} else {
+		tags := mergeTags(listStrings(ctx, plan.Tags, diags), defaults.tags, r.defaults.tags)
+		plan.TagsAll = stringListValue(ctx, types.ListNull(types.StringType), tags, diags)
+	}
+}
+
+// defaultRuleLanguage returns the query language Kibana uses for a rule type
+func defaultRuleLanguage(ruleType string) string {
+	switch ruleType {
+	case "eql":
+		return "eql"
+	case "esql":
+		return "esql"
+	case "machine_learning":
+		return ""
+	}
+	return "kuery"
+}
+
+func defaultString(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+func (r *RuleResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data *RuleResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Create, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resolveExceptionListIds(ctx, r.client, data.SpaceId.ValueString(), data.ExceptionsList, &resp.Diagnostics)
+	body := r.requestBody(ctx, data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Create the rule through API
+	var response transferobjects.DetectionRuleResponse
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Post(ctx, "/detection_engine/rules", body, &response, nil); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
+		return
+	}
+
+	// Save the identifiers generated by Kibana into the Terraform state
+	data.Id = types.StringValue(response.ID)
+	if data.RuleId.IsUnknown() {
+		data.RuleId = stringValue(types.StringNull(), response.RuleID)
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RuleResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data *RuleResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Read, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Get the rule through the API
+	var response transferobjects.DetectionRuleResponse
+	apiPath := fmt.Sprintf("/detection_engine/rules?id=%s", data.Id.ValueString())
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Get(ctx, apiPath, &response); err != nil {
+		if removeMissingResource(ctx, err, resp) {
+			return
+		}
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
+		return
+	}
+
+	// Every attribute is read back, so changes made outside of Terraform show up in the plan
+	data.fromDetectionRule(ctx, &response.DetectionRule, r.defaults, &resp.Diagnostics)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RuleResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data *RuleResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Update, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resolveExceptionListIds(ctx, r.client, data.SpaceId.ValueString(), data.ExceptionsList, &resp.Diagnostics)
+	body := r.requestBody(ctx, data, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// Kibana identifies the rule by either `rule_id` or `id`, not both
+	if _, ok := body["rule_id"]; !ok {
+		body["id"] = data.Id.ValueString()
+	}
+
+	// Update the rule through API
+	var response transferobjects.DetectionRuleResponse
+	if err := r.client.WithSpace(data.SpaceId.ValueString()).Put(ctx, "/detection_engine/rules", body, &response, nil); err != nil {
+		addClientErrorDiagnostic(&resp.Diagnostics, err, path.Empty())
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *RuleResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data *RuleResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := withTimeout(ctx, data.Timeouts.Delete, &resp.Diagnostics)
+	defer cancel()
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Delete the rule through the API
+	apiPath := fmt.Sprintf("/detection_engine/rules?id=%s", data.Id.ValueString())
+	deleteObject(ctx, r.client.WithSpace(data.SpaceId.ValueString()), apiPath, &resp.Diagnostics)
+}
+
+// requestBody returns the body of a create or update request of the planned rule
+func (r *RuleResource) requestBody(ctx context.Context, data *RuleResourceModel, diags *diag.Diagnostics) map[string]interface{} {
+	rule, known := data.toDetectionRule(ctx, diags)
+	if !known {
+		diags.AddError("Unknown Exception Container",
+			"The ID of an exception container of the rule is not known. Please report this issue to the provider developers.")
+	}
+	if diags.HasError() {
+		return nil
+	}
+	body, err := ruleRequestBody(rule)
+	if err != nil {
+		diags.AddError("Parser Error", fmt.Sprintf("Unable to encode the rule, got error: %s", err))
+	}
+	return body
+}
+
+func (r *RuleResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importStateWithSpace(ctx, req, resp)
+}
+
+// jsonObjectValidator checks that a string attribute holds a JSON encoded object
+type jsonObjectValidator struct{}
+
+func (v jsonObjectValidator) Description(ctx context.Context) string {
+	return "value must be a JSON encoded object"
+}
+
+func (v jsonObjectValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v jsonObjectValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(req.ConfigValue.ValueString()), &values); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid JSON Object",
+			fmt.Sprintf("Expected a JSON encoded object, got: %s", err))
+	}
+}
+
+// jsonArrayValidator checks that a string attribute holds a JSON encoded array
+type jsonArrayValidator struct{}
+
+func (v jsonArrayValidator) Description(ctx context.Context) string {
+	return "value must be a JSON encoded array"
+}
+
+func (v jsonArrayValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v jsonArrayValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	var values []interface{}
+	if err := json.Unmarshal([]byte(req.ConfigValue.ValueString()), &values); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid JSON Array",
+			fmt.Sprintf("Expected a JSON encoded array, got: %s", err))
+	}
+}
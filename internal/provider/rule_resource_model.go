@@ -0,0 +1,710 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"reflect"
+	"terraform-provider-elastic-siem/internal/helpers"
+	"terraform-provider-elastic-siem/internal/provider/transferobjects"
+)
+
+// RuleResourceModel describes the resource data model of the typed detection rule
+type RuleResourceModel struct {
+	RuleId               types.String                `tfsdk:"rule_id"`
+	Name                 types.String                `tfsdk:"name"`
+	Description          types.String                `tfsdk:"description"`
+	Type                 types.String                `tfsdk:"type"`
+	Enabled              types.Bool                  `tfsdk:"enabled"`
+	RiskScore            types.Int64                 `tfsdk:"risk_score"`
+	Severity             types.String                `tfsdk:"severity"`
+	Query                types.String                `tfsdk:"query"`
+	Language             types.String                `tfsdk:"language"`
+	Index                types.List                  `tfsdk:"index"`
+	Filters              types.String                `tfsdk:"filters"`
+	SavedId              types.String                `tfsdk:"saved_id"`
+	Interval             types.String                `tfsdk:"interval"`
+	From                 types.String                `tfsdk:"from"`
+	To                   types.String                `tfsdk:"to"`
+	MaxSignals           types.Int64                 `tfsdk:"max_signals"`
+	Author               types.List                  `tfsdk:"author"`
+	License              types.String                `tfsdk:"license"`
+	Tags                 types.List                  `tfsdk:"tags"`
+	TagsAll              types.List                  `tfsdk:"tags_all"`
+	References           types.List                  `tfsdk:"references"`
+	FalsePositives       types.List                  `tfsdk:"false_positives"`
+	Note                 types.String                `tfsdk:"note"`
+	Setup                types.String                `tfsdk:"setup"`
+	BuildingBlockType    types.String                `tfsdk:"building_block_type"`
+	RuleNameOverride     types.String                `tfsdk:"rule_name_override"`
+	TimestampOverride    types.String                `tfsdk:"timestamp_override"`
+	EventCategoryField   types.String                `tfsdk:"event_category_field"`
+	TimestampField       types.String                `tfsdk:"timestamp_field"`
+	TiebreakerField      types.String                `tfsdk:"tiebreaker_field"`
+	AnomalyThreshold     types.Int64                 `tfsdk:"anomaly_threshold"`
+	MachineLearningJobId types.List                  `tfsdk:"machine_learning_job_id"`
+	NewTermsFields       types.List                  `tfsdk:"new_terms_fields"`
+	HistoryWindowStart   types.String                `tfsdk:"history_window_start"`
+	ThreatIndex          types.List                  `tfsdk:"threat_index"`
+	ThreatQuery          types.String                `tfsdk:"threat_query"`
+	ThreatIndicatorPath  types.String                `tfsdk:"threat_indicator_path"`
+	ThreatFilters        types.String                `tfsdk:"threat_filters"`
+	Threat               []RuleThreatModel           `tfsdk:"threat"`
+	Threshold            *RuleThresholdModel         `tfsdk:"threshold"`
+	ThreatMapping        []RuleThreatMappingModel    `tfsdk:"threat_mapping"`
+	RiskScoreMapping     []RuleRiskScoreMappingModel `tfsdk:"risk_score_mapping"`
+	SeverityMapping      []RuleSeverityMappingModel  `tfsdk:"severity_mapping"`
+	Actions              []RuleActionModel           `tfsdk:"actions"`
+	ExceptionsList       []ExceptionListModel        `tfsdk:"exceptions_list"`
+	AlertSuppression     *RuleAlertSuppressionModel  `tfsdk:"alert_suppression"`
+	SpaceId              types.String                `tfsdk:"space_id"`
+	Id                   types.String                `tfsdk:"id"`
+	Timeouts             timeouts.Value              `tfsdk:"timeouts"`
+}
+
+type RuleThreatModel struct {
+	Framework types.String               `tfsdk:"framework"`
+	Tactic    *RuleThreatEntryModel      `tfsdk:"tactic"`
+	Technique []RuleThreatTechniqueModel `tfsdk:"technique"`
+}
+
+// RuleThreatEntryModel describes a MITRE ATT&CK tactic or subtechnique
+type RuleThreatEntryModel struct {
+	Id        types.String `tfsdk:"id"`
+	Name      types.String `tfsdk:"name"`
+	Reference types.String `tfsdk:"reference"`
+}
+
+type RuleThreatTechniqueModel struct {
+	Id           types.String           `tfsdk:"id"`
+	Name         types.String           `tfsdk:"name"`
+	Reference    types.String           `tfsdk:"reference"`
+	Subtechnique []RuleThreatEntryModel `tfsdk:"subtechnique"`
+}
+
+type RuleThresholdModel struct {
+	Field       types.List                      `tfsdk:"field"`
+	Value       types.Int64                     `tfsdk:"value"`
+	Cardinality []RuleThresholdCardinalityModel `tfsdk:"cardinality"`
+}
+
+type RuleThresholdCardinalityModel struct {
+	Field types.String `tfsdk:"field"`
+	Value types.Int64  `tfsdk:"value"`
+}
+
+type RuleThreatMappingModel struct {
+	Entries []RuleThreatMappingEntryModel `tfsdk:"entries"`
+}
+
+type RuleThreatMappingEntryModel struct {
+	Field types.String `tfsdk:"field"`
+	Type  types.String `tfsdk:"type"`
+	Value types.String `tfsdk:"value"`
+}
+
+type RuleRiskScoreMappingModel struct {
+	Field    types.String `tfsdk:"field"`
+	Operator types.String `tfsdk:"operator"`
+	Value    types.String `tfsdk:"value"`
+}
+
+type RuleSeverityMappingModel struct {
+	Field    types.String `tfsdk:"field"`
+	Operator types.String `tfsdk:"operator"`
+	Value    types.String `tfsdk:"value"`
+	Severity types.String `tfsdk:"severity"`
+}
+
+type RuleActionModel struct {
+	Group        types.String                 `tfsdk:"group"`
+	Id           types.String                 `tfsdk:"id"`
+	ActionTypeId types.String                 `tfsdk:"action_type_id"`
+	Params       types.String                 `tfsdk:"params"`
+	Frequency    *RuleActionFrequencyModel    `tfsdk:"frequency"`
+	AlertsFilter *RuleActionAlertsFilterModel `tfsdk:"alerts_filter"`
+}
+
+type RuleActionFrequencyModel struct {
+	Summary    types.Bool   `tfsdk:"summary"`
+	NotifyWhen types.String `tfsdk:"notify_when"`
+	Throttle   types.String `tfsdk:"throttle"`
+}
+
+type RuleActionAlertsFilterModel struct {
+	Query     *RuleAlertsFilterQueryModel     `tfsdk:"query"`
+	Timeframe *RuleAlertsFilterTimeframeModel `tfsdk:"timeframe"`
+}
+
+type RuleAlertsFilterQueryModel struct {
+	Kql     types.String `tfsdk:"kql"`
+	Filters types.String `tfsdk:"filters"`
+}
+
+type RuleAlertsFilterTimeframeModel struct {
+	Days       types.List   `tfsdk:"days"`
+	Timezone   types.String `tfsdk:"timezone"`
+	HoursStart types.String `tfsdk:"hours_start"`
+	HoursEnd   types.String `tfsdk:"hours_end"`
+}
+
+type RuleAlertSuppressionModel struct {
+	GroupBy               types.List                    `tfsdk:"group_by"`
+	Duration              *RuleSuppressionDurationModel `tfsdk:"duration"`
+	MissingFieldsStrategy types.String                  `tfsdk:"missing_fields_strategy"`
+}
+
+type RuleSuppressionDurationModel struct {
+	Value types.Int64  `tfsdk:"value"`
+	Unit  types.String `tfsdk:"unit"`
+}
+
+// toDetectionRule converts the planned rule into the transfer object sent to Kibana, the tags are
+// taken from `tags_all` so they include the provider defaults. It reports whether the IDs of all
+// exception containers are known.
+func (data *RuleResourceModel) toDetectionRule(ctx context.Context, diags *diag.Diagnostics) (*transferobjects.DetectionRule, bool) {
+	rule := &transferobjects.DetectionRule{
+		RuleID:              data.RuleId.ValueString(),
+		Name:                data.Name.ValueString(),
+		Description:         data.Description.ValueString(),
+		Type:                data.Type.ValueString(),
+		Enabled:             data.Enabled.ValueBoolPointer(),
+		RiskScore:           int(data.RiskScore.ValueInt64()),
+		Severity:            data.Severity.ValueString(),
+		Query:               data.Query.ValueString(),
+		Language:            data.Language.ValueString(),
+		Index:               listStrings(ctx, data.Index, diags),
+		Filters:             jsonArray(data.Filters, diags),
+		SavedID:             data.SavedId.ValueString(),
+		Interval:            data.Interval.ValueString(),
+		From:                data.From.ValueString(),
+		To:                  data.To.ValueString(),
+		MaxSignals:          int(data.MaxSignals.ValueInt64()),
+		Author:              listStrings(ctx, data.Author, diags),
+		License:             data.License.ValueString(),
+		Tags:                listStrings(ctx, data.TagsAll, diags),
+		References:          listInterfaces(ctx, data.References, diags),
+		FalsePositives:      listInterfaces(ctx, data.FalsePositives, diags),
+		Note:                data.Note.ValueString(),
+		Setup:               data.Setup.ValueString(),
+		BuildingBlockTYpe:   data.BuildingBlockType.ValueString(),
+		RuleNameOverride:    data.RuleNameOverride.ValueString(),
+		TimeStampOverride:   data.TimestampOverride.ValueString(),
+		EventCategoryField:  data.EventCategoryField.ValueString(),
+		TimestampField:      data.TimestampField.ValueString(),
+		TiebreakerField:     data.TiebreakerField.ValueString(),
+		AnomalyThreshold:    int(data.AnomalyThreshold.ValueInt64()),
+		MachineLeanJID:      listStrings(ctx, data.MachineLearningJobId, diags),
+		NewTermsFields:      listStrings(ctx, data.NewTermsFields, diags),
+		HistoryWindowStart:  data.HistoryWindowStart.ValueString(),
+		ThreatIndex:         listStrings(ctx, data.ThreatIndex, diags),
+		ThreatQuery:         data.ThreatQuery.ValueString(),
+		ThreatIndicatorPath: data.ThreatIndicatorPath.ValueString(),
+		ThreatFilters:       jsonArray(data.ThreatFilters, diags),
+	}
+
+	for _, threat := range data.Threat {
+		item := transferobjects.ThreatItem{Framework: threat.Framework.ValueString()}
+		if threat.Tactic != nil {
+			item.Tactic = transferobjects.ThreatTactic{
+				ID:        threat.Tactic.Id.ValueString(),
+				Name:      threat.Tactic.Name.ValueString(),
+				Reference: threat.Tactic.Reference.ValueString(),
+			}
+		}
+		for _, technique := range threat.Technique {
+			threatTechnique := transferobjects.ThreatTechnique{
+				ID:        technique.Id.ValueString(),
+				Name:      technique.Name.ValueString(),
+				Reference: technique.Reference.ValueString(),
+			}
+			for _, subtechnique := range technique.Subtechnique {
+				threatTechnique.Subtechnique = append(threatTechnique.Subtechnique, transferobjects.ThreatSubtechnique{
+					ID:        subtechnique.Id.ValueString(),
+					Name:      subtechnique.Name.ValueString(),
+					Reference: subtechnique.Reference.ValueString(),
+				})
+			}
+			item.Technique = append(item.Technique, threatTechnique)
+		}
+		rule.Threat = append(rule.Threat, item)
+	}
+
+	if data.Threshold != nil {
+		rule.Threshold = transferobjects.RuleThreshold{
+			Field: listStrings(ctx, data.Threshold.Field, diags),
+			Value: int(data.Threshold.Value.ValueInt64()),
+		}
+		for _, cardinality := range data.Threshold.Cardinality {
+			rule.Threshold.Cardinality = append(rule.Threshold.Cardinality, transferobjects.ThresholdCardinality{
+				Field: cardinality.Field.ValueString(),
+				Value: int(cardinality.Value.ValueInt64()),
+			})
+		}
+	}
+
+	for _, mapping := range data.ThreatMapping {
+		var threatMapping transferobjects.ThreatMapping
+		for _, entry := range mapping.Entries {
+			threatMapping.Entries = append(threatMapping.Entries, transferobjects.ThreatMappingEntry{
+				Field: entry.Field.ValueString(),
+				Type:  entry.Type.ValueString(),
+				Value: entry.Value.ValueString(),
+			})
+		}
+		rule.ThreatMapping = append(rule.ThreatMapping, threatMapping)
+	}
+
+	for _, mapping := range data.RiskScoreMapping {
+		rule.RiskScoreMapping = append(rule.RiskScoreMapping, transferobjects.RiskScoreMapping{
+			Field:    mapping.Field.ValueString(),
+			Operator: mapping.Operator.ValueString(),
+			Value:    mapping.Value.ValueString(),
+		})
+	}
+
+	for _, mapping := range data.SeverityMapping {
+		rule.SeverityMapping = append(rule.SeverityMapping, transferobjects.SeverityMapping{
+			Field:    mapping.Field.ValueString(),
+			Operator: mapping.Operator.ValueString(),
+			Value:    mapping.Value.ValueString(),
+			Severity: mapping.Severity.ValueString(),
+		})
+	}
+
+	for _, action := range data.Actions {
+		rule.Actions = append(rule.Actions, action.toActionItem(ctx, diags))
+	}
+
+	exceptionLists, known := exceptionListItems(data.ExceptionsList)
+	rule.ExceptionsList = exceptionLists
+
+	if data.AlertSuppression != nil {
+		rule.AlertSuppression = &transferobjects.AlertSuppression{
+			GroupBy:               listStrings(ctx, data.AlertSuppression.GroupBy, diags),
+			MissingFieldsStrategy: data.AlertSuppression.MissingFieldsStrategy.ValueString(),
+		}
+		if duration := data.AlertSuppression.Duration; duration != nil {
+			rule.AlertSuppression.Duration = &transferobjects.SuppressionDuration{
+				Value: int(duration.Value.ValueInt64()),
+				Unit:  duration.Unit.ValueString(),
+			}
+		}
+	}
+	return rule, known
+}
+
+// ruleRequestBody returns the body of a create or update request. `enabled` is always sent, Kibana would
+// otherwise enable a rule it is omitted from, as are `risk_score` and the `anomaly_threshold` of a
+// `machine_learning` rule, which may be 0. An unset threshold is removed.
+func ruleRequestBody(rule *transferobjects.DetectionRule) (map[string]interface{}, error) {
+	var body map[string]interface{}
+	if err := convertJSON(rule, &body); err != nil {
+		return nil, err
+	}
+	body["enabled"] = rule.Enabled != nil && *rule.Enabled
+	body["risk_score"] = rule.RiskScore
+	if rule.Type == "machine_learning" {
+		body["anomaly_threshold"] = rule.AnomalyThreshold
+	}
+	if rule.Threshold.Value == 0 && len(rule.Threshold.Field) == 0 {
+		delete(body, "threshold")
+	}
+	return body, nil
+}
+
+// fromDetectionRule writes the rule read from Kibana into the model. Values Kibana returns empty are kept
+// as they are in the prior state, so an empty list in the configuration is not reported as a change.
+func (data *RuleResourceModel) fromDetectionRule(ctx context.Context, rule *transferobjects.DetectionRule, defaults resourceDefaults, diags *diag.Diagnostics) {
+	data.Id = types.StringValue(rule.ID)
+	data.RuleId = stringValue(data.RuleId, rule.RuleID)
+	data.Name = stringValue(data.Name, rule.Name)
+	data.Description = stringValue(data.Description, rule.Description)
+	data.Type = stringValue(data.Type, rule.Type)
+	data.Enabled = types.BoolValue(rule.Enabled != nil && *rule.Enabled)
+	data.RiskScore = types.Int64Value(int64(rule.RiskScore))
+	data.Severity = stringValue(data.Severity, rule.Severity)
+	data.Query = stringValue(data.Query, rule.Query)
+	data.Language = stringValue(data.Language, rule.Language)
+	data.Index = stringListValue(ctx, data.Index, rule.Index, diags)
+	data.Filters = jsonArrayValue(data.Filters, rule.Filters, diags)
+	data.SavedId = stringValue(data.SavedId, rule.SavedID)
+	data.Interval = stringValue(data.Interval, rule.Interval)
+	data.From = stringValue(data.From, rule.From)
+	data.To = stringValue(data.To, rule.To)
+	data.MaxSignals = int64Value(rule.MaxSignals)
+	data.Author = stringListValue(ctx, data.Author, rule.Author, diags)
+	data.License = stringValue(data.License, rule.License)
+	data.References = stringListValue(ctx, data.References, interfaceStrings(rule.References), diags)
+	data.FalsePositives = stringListValue(ctx, data.FalsePositives, interfaceStrings(rule.FalsePositives), diags)
+	data.Note = stringValue(data.Note, rule.Note)
+	data.Setup = stringValue(data.Setup, rule.Setup)
+	data.BuildingBlockType = stringValue(data.BuildingBlockType, rule.BuildingBlockTYpe)
+	data.RuleNameOverride = stringValue(data.RuleNameOverride, rule.RuleNameOverride)
+	data.TimestampOverride = stringValue(data.TimestampOverride, rule.TimeStampOverride)
+	data.EventCategoryField = stringValue(data.EventCategoryField, rule.EventCategoryField)
+	data.TimestampField = stringValue(data.TimestampField, rule.TimestampField)
+	data.TiebreakerField = stringValue(data.TiebreakerField, rule.TiebreakerField)
+	data.AnomalyThreshold = int64Value(rule.AnomalyThreshold)
+	if rule.Type == "machine_learning" {
+		// Required by the rule type, a threshold of 0 is a value
+		data.AnomalyThreshold = types.Int64Value(int64(rule.AnomalyThreshold))
+	}
+	data.MachineLearningJobId = stringListValue(ctx, data.MachineLearningJobId, rule.MachineLeanJID, diags)
+	data.NewTermsFields = stringListValue(ctx, data.NewTermsFields, rule.NewTermsFields, diags)
+	data.HistoryWindowStart = stringValue(data.HistoryWindowStart, rule.HistoryWindowStart)
+	data.ThreatIndex = stringListValue(ctx, data.ThreatIndex, rule.ThreatIndex, diags)
+	data.ThreatQuery = stringValue(data.ThreatQuery, rule.ThreatQuery)
+	data.ThreatIndicatorPath = stringValue(data.ThreatIndicatorPath, rule.ThreatIndicatorPath)
+	data.ThreatFilters = jsonArrayValue(data.ThreatFilters, rule.ThreatFilters, diags)
+
+	// Tags added by the provider defaults are only part of `tags_all`
+	var priorTags []string
+	if !data.Tags.IsNull() && !data.Tags.IsUnknown() {
+		diags.Append(data.Tags.ElementsAs(ctx, &priorTags, false)...)
+	}
+	defaultTags := mergeTags(defaults.rule.tags, defaults.tags)
+	var tags []string
+	for _, tag := range rule.Tags {
+		if helpers.Contains(priorTags, tag) || !helpers.Contains(defaultTags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	data.Tags = stringListValue(ctx, data.Tags, tags, diags)
+	data.TagsAll = stringListValue(ctx, data.TagsAll, rule.Tags, diags)
+
+	data.Threat = make([]RuleThreatModel, 0, len(rule.Threat))
+	for _, item := range rule.Threat {
+		threat := RuleThreatModel{
+			Framework: types.StringValue(item.Framework),
+			Tactic: &RuleThreatEntryModel{
+				Id:        types.StringValue(item.Tactic.ID),
+				Name:      types.StringValue(item.Tactic.Name),
+				Reference: types.StringValue(item.Tactic.Reference),
+			},
+			Technique: make([]RuleThreatTechniqueModel, 0, len(item.Technique)),
+		}
+		for _, technique := range item.Technique {
+			threatTechnique := RuleThreatTechniqueModel{
+				Id:           types.StringValue(technique.ID),
+				Name:         types.StringValue(technique.Name),
+				Reference:    types.StringValue(technique.Reference),
+				Subtechnique: make([]RuleThreatEntryModel, 0, len(technique.Subtechnique)),
+			}
+			for _, subtechnique := range technique.Subtechnique {
+				threatTechnique.Subtechnique = append(threatTechnique.Subtechnique, RuleThreatEntryModel{
+					Id:        types.StringValue(subtechnique.ID),
+					Name:      types.StringValue(subtechnique.Name),
+					Reference: types.StringValue(subtechnique.Reference),
+				})
+			}
+			threat.Technique = append(threat.Technique, threatTechnique)
+		}
+		data.Threat = append(data.Threat, threat)
+	}
+
+	if rule.Threshold.Value == 0 && len(rule.Threshold.Field) == 0 {
+		data.Threshold = nil
+	} else {
+		var priorField types.List
+		if data.Threshold != nil {
+			priorField = data.Threshold.Field
+		}
+		threshold := &RuleThresholdModel{
+			Field:       stringListValue(ctx, priorField, rule.Threshold.Field, diags),
+			Value:       types.Int64Value(int64(rule.Threshold.Value)),
+			Cardinality: make([]RuleThresholdCardinalityModel, 0, len(rule.Threshold.Cardinality)),
+		}
+		for _, cardinality := range rule.Threshold.Cardinality {
+			threshold.Cardinality = append(threshold.Cardinality, RuleThresholdCardinalityModel{
+				Field: types.StringValue(cardinality.Field),
+				Value: types.Int64Value(int64(cardinality.Value)),
+			})
+		}
+		data.Threshold = threshold
+	}
+
+	data.ThreatMapping = make([]RuleThreatMappingModel, 0, len(rule.ThreatMapping))
+	for _, mapping := range rule.ThreatMapping {
+		threatMapping := RuleThreatMappingModel{Entries: make([]RuleThreatMappingEntryModel, 0, len(mapping.Entries))}
+		for _, entry := range mapping.Entries {
+			threatMapping.Entries = append(threatMapping.Entries, RuleThreatMappingEntryModel{
+				Field: types.StringValue(entry.Field),
+				Type:  types.StringValue(entry.Type),
+				Value: types.StringValue(entry.Value),
+			})
+		}
+		data.ThreatMapping = append(data.ThreatMapping, threatMapping)
+	}
+
+	data.RiskScoreMapping = make([]RuleRiskScoreMappingModel, 0, len(rule.RiskScoreMapping))
+	for _, mapping := range rule.RiskScoreMapping {
+		data.RiskScoreMapping = append(data.RiskScoreMapping, RuleRiskScoreMappingModel{
+			Field:    types.StringValue(mapping.Field),
+			Operator: types.StringValue(mapping.Operator),
+			Value:    types.StringValue(mapping.Value),
+		})
+	}
+
+	data.SeverityMapping = make([]RuleSeverityMappingModel, 0, len(rule.SeverityMapping))
+	for _, mapping := range rule.SeverityMapping {
+		data.SeverityMapping = append(data.SeverityMapping, RuleSeverityMappingModel{
+			Field:    types.StringValue(mapping.Field),
+			Operator: types.StringValue(mapping.Operator),
+			Value:    types.StringValue(mapping.Value),
+			Severity: types.StringValue(mapping.Severity),
+		})
+	}
+
+	priorActions := data.Actions
+	data.Actions = make([]RuleActionModel, 0, len(rule.Actions))
+	for i, item := range rule.Actions {
+		var prior RuleActionModel
+		if i < len(priorActions) {
+			prior = priorActions[i]
+		}
+		data.Actions = append(data.Actions, actionModel(ctx, prior, item, diags))
+	}
+
+	data.ExceptionsList = make([]ExceptionListModel, 0, len(rule.ExceptionsList))
+	for _, item := range rule.ExceptionsList {
+		data.ExceptionsList = append(data.ExceptionsList, ExceptionListModel{
+			Id:            types.StringValue(item.ID),
+			ListId:        types.StringValue(item.ListID),
+			Type:          types.StringValue(item.Type),
+			NamespaceType: types.StringValue(item.NamespaceType),
+		})
+	}
+
+	if rule.AlertSuppression == nil {
+		data.AlertSuppression = nil
+	} else {
+		var priorGroupBy types.List
+		if data.AlertSuppression != nil {
+			priorGroupBy = data.AlertSuppression.GroupBy
+		}
+		suppression := &RuleAlertSuppressionModel{
+			GroupBy:               stringListValue(ctx, priorGroupBy, rule.AlertSuppression.GroupBy, diags),
+			MissingFieldsStrategy: types.StringValue(rule.AlertSuppression.MissingFieldsStrategy),
+		}
+		if duration := rule.AlertSuppression.Duration; duration != nil {
+			suppression.Duration = &RuleSuppressionDurationModel{
+				Value: types.Int64Value(int64(duration.Value)),
+				Unit:  types.StringValue(duration.Unit),
+			}
+		}
+		data.AlertSuppression = suppression
+	}
+}
+
+// convertJSON converts a value into another type with the same JSON representation
+func convertJSON(value interface{}, result interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, result)
+}
+
+func listStrings(ctx context.Context, list types.List, diags *diag.Diagnostics) []string {
+	var values []string
+	if list.IsNull() || list.IsUnknown() {
+		return values
+	}
+	diags.Append(list.ElementsAs(ctx, &values, false)...)
+	return values
+}
+
+func listInterfaces(ctx context.Context, list types.List, diags *diag.Diagnostics) []interface{} {
+	var values []interface{}
+	for _, value := range listStrings(ctx, list, diags) {
+		values = append(values, value)
+	}
+	return values
+}
+
+func interfaceStrings(values []interface{}) []string {
+	var result []string
+	for _, value := range values {
+		result = append(result, fmt.Sprint(value))
+	}
+	return result
+}
+
+// jsonArray decodes an attribute holding a JSON encoded array
+func jsonArray(value types.String, diags *diag.Diagnostics) []interface{} {
+	var result []interface{}
+	if value.IsNull() || value.IsUnknown() {
+		return result
+	}
+	if err := json.Unmarshal([]byte(value.ValueString()), &result); err != nil {
+		diags.AddError("Parser Error", fmt.Sprintf("Unable to parse the JSON array, got error: %s", err))
+	}
+	return result
+}
+
+// stringValue returns the value read from Kibana, an empty string is null unless the prior state has it
+func stringValue(prior types.String, value string) types.String {
+	if value != "" {
+		return types.StringValue(value)
+	}
+	if !prior.IsNull() && !prior.IsUnknown() && prior.ValueString() == "" {
+		return prior
+	}
+	return types.StringNull()
+}
+
+func int64Value(value int) types.Int64 {
+	if value == 0 {
+		return types.Int64Null()
+	}
+	return types.Int64Value(int64(value))
+}
+
+// stringListValue returns the list read from Kibana, an empty list is null unless the prior state has it
+func stringListValue(ctx context.Context, prior types.List, values []string, diags *diag.Diagnostics) types.List {
+	if len(values) == 0 {
+		if !prior.IsNull() && !prior.IsUnknown() && len(prior.Elements()) == 0 {
+			return prior
+		}
+		return types.ListNull(types.StringType)
+	}
+	list, listDiags := types.ListValueFrom(ctx, types.StringType, values)
+	diags.Append(listDiags...)
+	return list
+}
+
+// jsonArrayValue returns the array read from Kibana as JSON, the prior state is kept when it holds the same array
+func jsonArrayValue(prior types.String, values []interface{}, diags *diag.Diagnostics) types.String {
+	if !prior.IsNull() && !prior.IsUnknown() {
+		var priorValues []interface{}
+		if err := json.Unmarshal([]byte(prior.ValueString()), &priorValues); err == nil &&
+			(reflect.DeepEqual(priorValues, values) || len(priorValues) == 0 && len(values) == 0) {
+			return prior
+		}
+	}
+	if len(values) == 0 {
+		return types.StringNull()
+	}
+	content, err := json.Marshal(values)
+	if err != nil {
+		diags.AddError("Parser Error", fmt.Sprintf("Unable to encode the JSON array, got error: %s", err))
+	}
+	return types.StringValue(string(content))
+}
+
+func (m RuleActionModel) toActionItem(ctx context.Context, diags *diag.Diagnostics) transferobjects.ActionItem {
+	item := transferobjects.ActionItem{
+		Group:        m.Group.ValueString(),
+		ID:           m.Id.ValueString(),
+		ActionTypeID: m.ActionTypeId.ValueString(),
+	}
+	if !m.Params.IsNull() && !m.Params.IsUnknown() {
+		if err := json.Unmarshal([]byte(m.Params.ValueString()), &item.Params); err != nil {
+			diags.AddError("Parser Error", fmt.Sprintf("Unable to parse the action params, got error: %s", err))
+		}
+	}
+	if frequency := m.Frequency; frequency != nil {
+		item.Frequency = &transferobjects.ActionFrequency{
+			Summary:    frequency.Summary.ValueBool(),
+			NotifyWhen: frequency.NotifyWhen.ValueString(),
+			Throttle:   frequency.Throttle.ValueStringPointer(),
+		}
+	}
+	if filter := m.AlertsFilter; filter != nil {
+		item.AlertsFilter = &transferobjects.ActionAlertsFilter{}
+		if query := filter.Query; query != nil {
+			item.AlertsFilter.Query = &transferobjects.AlertsFilterQuery{
+				Kql:     query.Kql.ValueString(),
+				Filters: jsonArray(query.Filters, diags),
+			}
+			if item.AlertsFilter.Query.Filters == nil {
+				// Kibana requires the filters
+				item.AlertsFilter.Query.Filters = []interface{}{}
+			}
+		}
+		if timeframe := filter.Timeframe; timeframe != nil {
+			var days []int
+			if !timeframe.Days.IsNull() && !timeframe.Days.IsUnknown() {
+				diags.Append(timeframe.Days.ElementsAs(ctx, &days, false)...)
+			}
+			item.AlertsFilter.Timeframe = &transferobjects.AlertsFilterTimeframe{
+				Days:     days,
+				Timezone: timeframe.Timezone.ValueString(),
+				Hours: transferobjects.AlertsFilterHours{
+					Start: timeframe.HoursStart.ValueString(),
+					End:   timeframe.HoursEnd.ValueString(),
+				},
+			}
+		}
+	}
+	return item
+}
+
+// actionModel returns the action read from Kibana, values equal to the prior state keep its formatting
+func actionModel(ctx context.Context, prior RuleActionModel, item transferobjects.ActionItem, diags *diag.Diagnostics) RuleActionModel {
+	action := RuleActionModel{
+		Group:        types.StringValue(item.Group),
+		Id:           types.StringValue(item.ID),
+		ActionTypeId: types.StringValue(item.ActionTypeID),
+		Params:       jsonObjectValue(prior.Params, item.Params, diags),
+	}
+	// Kibana adds its default frequency to an action without one
+	if frequency := item.Frequency; frequency != nil && (prior.Frequency != nil || !isDefaultActionFrequency(frequency)) {
+		action.Frequency = &RuleActionFrequencyModel{
+			Summary:    types.BoolValue(frequency.Summary),
+			NotifyWhen: types.StringValue(frequency.NotifyWhen),
+			Throttle:   types.StringPointerValue(frequency.Throttle),
+		}
+	}
+	if filter := item.AlertsFilter; filter != nil {
+		action.AlertsFilter = &RuleActionAlertsFilterModel{}
+		var priorFilter RuleActionAlertsFilterModel
+		if prior.AlertsFilter != nil {
+			priorFilter = *prior.AlertsFilter
+		}
+		if query := filter.Query; query != nil {
+			priorFilters := types.StringNull()
+			if priorFilter.Query != nil {
+				priorFilters = priorFilter.Query.Filters
+			}
+			action.AlertsFilter.Query = &RuleAlertsFilterQueryModel{
+				Kql:     types.StringValue(query.Kql),
+				Filters: jsonArrayValue(priorFilters, query.Filters, diags),
+			}
+		}
+		if timeframe := filter.Timeframe; timeframe != nil {
+			days, daysDiags := types.ListValueFrom(ctx, types.Int64Type, timeframe.Days)
+			diags.Append(daysDiags...)
+			action.AlertsFilter.Timeframe = &RuleAlertsFilterTimeframeModel{
+				Days:       days,
+				Timezone:   types.StringValue(timeframe.Timezone),
+				HoursStart: types.StringValue(timeframe.Hours.Start),
+				HoursEnd:   types.StringValue(timeframe.Hours.End),
+			}
+		}
+	}
+	return action
+}
+
+// jsonObjectValue returns the object read from Kibana as JSON, the prior state is kept when it holds the same object
+func jsonObjectValue(prior types.String, values map[string]interface{}, diags *diag.Diagnostics) types.String {
+	if !prior.IsNull() && !prior.IsUnknown() {
+		var priorValues map[string]interface{}
+		if err := json.Unmarshal([]byte(prior.ValueString()), &priorValues); err == nil &&
+			(reflect.DeepEqual(priorValues, values) || len(priorValues) == 0 && len(values) == 0) {
+			return prior
+		}
+	}
+	if len(values) == 0 {
+		return types.StringNull()
+	}
+	content, err := json.Marshal(values)
+	if err != nil {
+		diags.AddError("Parser Error", fmt.Sprintf("Unable to encode the JSON object, got error: %s", err))
+	}
+	return types.StringValue(string(content))
+}
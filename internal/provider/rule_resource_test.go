@@ -0,0 +1,204 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"terraform-provider-elastic-siem/internal/fakeserver"
+	"terraform-provider-elastic-siem/internal/provider/transferobjects"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/terraform"
+)
+
+func TestAccRuleResource(t *testing.T) {
+	apiServerObjects := make(map[string]map[string]interface{})
+	svr := fakeserver.NewFakeServer(test_post, apiServerObjects, true, false, "")
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			svr.StartInBackground()
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// Blocks are validated when they are set
+			{
+				Config:      strings.Replace(testAccRuleResourceConfig("high", ""), "value = 10", "", 1),
+				ExpectError: regexp.MustCompile(`Attribute "threshold.value" must be specified`),
+			},
+			// Create and Read testing
+			{
+				Config: testAccRuleResourceConfig("high", "enabled = false"),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "rule_id", "7ce764f6-36a7-4e72-ab8b-166170cd1c93"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "enabled", "false"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "interval", "5m"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "from", "now-6m"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "max_signals", "100"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "language", "kuery"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "threshold.value", "10"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "threat.0.framework", "MITRE ATT&CK"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "threat.0.technique.0.subtechnique.0.id", "T1059.001"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "exceptions_list.0.namespace_type", "single"),
+					func(s *terraform.State) error {
+						rule := apiServerObjects["rules"]
+						if rule["enabled"] != false {
+							return fmt.Errorf("expected the rule to be created disabled, got enabled %v", rule["enabled"])
+						}
+						if _, ok := rule["threat"].([]interface{})[0].(map[string]interface{})["technique"].([]interface{})[0].(map[string]interface{})["subtechnique"]; !ok {
+							return fmt.Errorf("expected the subtechnique inside its technique, got %v", rule["threat"])
+						}
+						if rule["risk_score_mapping"].([]interface{})[0].(map[string]interface{})["value"] != "" {
+							return fmt.Errorf("expected an empty risk score mapping value, got %v", rule["risk_score_mapping"])
+						}
+						var actions []transferobjects.ActionItem
+						if err := convertJSON(rule["actions"], &actions); err != nil {
+							return err
+						}
+						if len(actions) != 1 || actions[0].Params["message"] == nil || actions[0].Frequency == nil ||
+							*actions[0].Frequency.Throttle != "1h" || !actions[0].Frequency.Summary ||
+							actions[0].AlertsFilter.Query.Filters == nil || actions[0].AlertsFilter.Timeframe.Hours.End != "17:00" {
+							return fmt.Errorf("unexpected actions: %v", rule["actions"])
+						}
+						return nil
+					},
+				),
+			},
+			// ImportState testing
+			{
+				ResourceName:      "elastic-siem_rule.test",
+				ImportState:       true,
+				ImportStateId:     "rule",
+				ImportStateVerify: true,
+			},
+			// A rule changed in Kibana is planned to be restored
+			{
+				PreConfig: func() {
+					apiServerObjects["rules"]["severity"] = "low"
+				},
+				Config:             testAccRuleResourceConfig("high", "enabled = false"),
+				PlanOnly:           true,
+				ExpectNonEmptyPlan: true,
+			},
+			// Update and Read testing
+			{
+				Config: testAccRuleResourceConfig("critical", ""),
+				Check: resource.ComposeAggregateTestCheckFunc(
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "severity", "critical"),
+					resource.TestCheckResourceAttr("elastic-siem_rule.test", "enabled", "true"),
+					func(s *terraform.State) error {
+						if apiServerObjects["rules"]["enabled"] != true {
+							return fmt.Errorf("expected the rule to be enabled, got %v", apiServerObjects["rules"]["enabled"])
+						}
+						return nil
+					},
+				),
+			},
+			// Delete testing automatically occurs in TestCase
+		},
+	})
+
+	svr.Shutdown()
+}
+
+func testAccRuleResourceConfig(severity string, extra string) string {
+	return fmt.Sprintf(`%s
+resource "elastic-siem_rule" "test" {
+  rule_id     = "7ce764f6-36a7-4e72-ab8b-166170cd1c93"
+  name        = "Many failed logins"
+  description = "Detects many failed logins of a user"
+  type        = "threshold"
+  risk_score  = 47
+  severity    = %q
+  query       = "event.outcome : failure"
+  index       = ["logs-*"]
+  tags        = ["Linux"]
+  %s
+
+  threshold {
+    field = ["user.name"]
+    value = 10
+  }
+
+  threat {
+    tactic {
+      id        = "TA0002"
+      name      = "Execution"
+      reference = "https://attack.mitre.org/tactics/TA0002/"
+    }
+    technique {
+      id        = "T1059"
+      name      = "Command and Scripting Interpreter"
+      reference = "https://attack.mitre.org/techniques/T1059/"
+      subtechnique {
+        id        = "T1059.001"
+        name      = "PowerShell"
+        reference = "https://attack.mitre.org/techniques/T1059/001/"
+      }
+    }
+  }
+
+  risk_score_mapping {
+    field = "risk.calculated_score_norm"
+  }
+
+  exceptions_list {
+    id      = "container-id"
+    list_id = "container-list-id"
+  }
+
+  actions {
+    id             = "slack-connector-id"
+    action_type_id = ".slack"
+    params         = jsonencode({ message = "{{context.rule.name}} created {{state.signals_count}} alerts" })
+
+    frequency {
+      notify_when = "onThrottleInterval"
+      throttle    = "1h"
+    }
+
+    alerts_filter {
+      query {
+        kql = "host.os.type : linux"
+      }
+      timeframe {
+        days        = [1, 2, 3, 4, 5]
+        timezone    = "Europe/Berlin"
+        hours_start = "08:00"
+        hours_end   = "17:00"
+      }
+    }
+  }
+}
+`, providerConfig, severity, extra)
+}
+
+func TestRuleRequestBody(t *testing.T) {
+	enabled := false
+	body, err := ruleRequestBody(&transferobjects.DetectionRule{
+		Name:    "ML rule",
+		Type:    "machine_learning",
+		Enabled: &enabled,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"enabled", "risk_score", "anomaly_threshold"} {
+		if value, ok := body[key]; !ok || (value != false && value != 0) {
+			t.Errorf("expected %s to be sent with its zero value, got %v", key, body)
+		}
+	}
+	if _, ok := body["threshold"]; ok {
+		t.Errorf("expected the unset threshold to be removed, got %v", body)
+	}
+
+	body, err = ruleRequestBody(&transferobjects.DetectionRule{Name: "Query rule", Type: "query", RiskScore: 21})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := body["anomaly_threshold"]; ok {
+		t.Errorf("expected no anomaly threshold for a query rule, got %v", body)
+	}
+}
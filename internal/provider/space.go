@@ -0,0 +1,41 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"strings"
+)
+
+// spaceIdAttribute returns the schema of the per-resource Kibana space override
+func spaceIdAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "The Kibana space the object belongs to (overrides the provider `space_id`)",
+		Optional:            true,
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.RequiresReplace(),
+		},
+	}
+}
+
+// importStateWithSpace imports a resource either by `<id>` or by `<space_id>/<id>`
+func importStateWithSpace(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	spaceID, id, found := strings.Cut(req.ID, "/")
+	if !found {
+		resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+		return
+	}
+
+	if spaceID == "" || id == "" {
+		resp.Diagnostics.AddError("Invalid Import Identifier",
+			fmt.Sprintf("Expected an import identifier in the form `<id>` or `<space_id>/<id>`, got: %s", req.ID))
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("space_id"), spaceID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+}
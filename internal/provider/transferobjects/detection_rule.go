@@ -3,39 +3,52 @@ package transferobjects
 import "time"
 
 type ThreatItem struct {
-	Framework string `json:"framework,omitempty"`
-	Tactic    struct {
-		ID        string `json:"id,omitempty"`
-		Name      string `json:"name,omitempty"`
-		Reference string `json:"reference,omitempty"`
-	} `json:"tactic,omitempty"`
-	Technique []struct {
-		ID        string `json:"id,omitempty"`
-		Name      string `json:"name,omitempty"`
-		Reference string `json:"reference,omitempty"`
-	} `json:"technique,omitempty"`
-	Subtechnique []struct {
-		ID        string `json:"id,omitempty"`
-		Name      string `json:"name,omitempty"`
-		Reference string `json:"reference,omitempty"`
-	} `json:"subtechnique,omitempty"`
+	Framework string            `json:"framework,omitempty"`
+	Tactic    ThreatTactic      `json:"tactic,omitempty"`
+	Technique []ThreatTechnique `json:"technique,omitempty"`
+	// Subtechnique is kept for rule content written against earlier versions, Kibana expects
+	// the subtechniques inside their technique
+	Subtechnique []ThreatSubtechnique `json:"subtechnique,omitempty"`
+}
+
+type ThreatTactic struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Reference string `json:"reference,omitempty"`
+}
+
+type ThreatTechnique struct {
+	ID           string               `json:"id,omitempty"`
+	Name         string               `json:"name,omitempty"`
+	Reference    string               `json:"reference,omitempty"`
+	Subtechnique []ThreatSubtechnique `json:"subtechnique,omitempty"`
+}
+
+type ThreatSubtechnique struct {
+	ID        string `json:"id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Reference string `json:"reference,omitempty"`
+}
+
+type ThreatMappingEntry struct {
+	Field string `json:"field,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+type ThresholdCardinality struct {
+	Field string `json:"field,omitempty"`
+	Value int    `json:"value,omitempty"`
 }
 
 type ThreatMapping struct {
-	Entries []struct {
-		Field string `json:"field,omitempty"`
-		Type  string `json:"type,omitempty"`
-		Value string `json:"value,omitempty"`
-	} `json:"entries,omitempty"`
+	Entries []ThreatMappingEntry `json:"entries,omitempty"`
 }
 
 type RuleThreshold struct {
-	Field       []string `json:"field,omitempty"`
-	Value       int      `json:"value,omitempty"`
-	Cardinality []struct {
-		Field string `json:"field,omitempty"`
-		Value int    `json:"value,omitempty"`
-	} `json:"cardinality,omitempty"`
+	Field       []string               `json:"field,omitempty"`
+	Value       int                    `json:"value,omitempty"`
+	Cardinality []ThresholdCardinality `json:"cardinality,omitempty"`
 }
 
 type ExecutionHistoryItem struct {
@@ -66,26 +79,59 @@ type SeverityMapping struct {
 }
 
 type ActionItem struct {
-	Group  string `json:"group,omitempty"`
-	ID     string `json:"id,omitempty"`
-	Params struct {
-		Message     string `json:"message,omitempty"`
-		To          string `json:"to,omitempty"`
-		Cc          string `json:"cc,omitempty"`
-		Bcc         string `json:"bcc,omitempty"`
-		Subject     string `json:"subject,omitempty"`
-		Body        string `json:"body,omitempty"`
-		Severity    string `json:"severity,omitempty"`
-		EventAction string `json:"eventAction,omitempty"`
-		DedupKey    string `json:"dedupKey,omitempty"`
-		Timestamp   string `json:"timestamp,omitempty"`
-		Component   string `json:"component,omitempty"`
-		Group       string `json:"group,omitempty"`
-		Source      string `json:"source,omitempty"`
-		Summary     string `json:"summary,omitempty"`
-		Class       string `json:"class,omitempty"`
-	} `json:"params,omitempty"`
-	ActionTypeID string `json:"action_type_id,omitempty"`
+	Group        string                 `json:"group,omitempty"`
+	ID           string                 `json:"id,omitempty"`
+	Params       map[string]interface{} `json:"params,omitempty"`
+	ActionTypeID string                 `json:"action_type_id,omitempty"`
+	Frequency    *ActionFrequency       `json:"frequency,omitempty"`
+	AlertsFilter *ActionAlertsFilter    `json:"alerts_filter,omitempty"`
+}
+
+type ActionFrequency struct {
+	Summary    bool    `json:"summary"`
+	NotifyWhen string  `json:"notifyWhen"`
+	Throttle   *string `json:"throttle"`
+}
+
+type ActionAlertsFilter struct {
+	Query     *AlertsFilterQuery     `json:"query,omitempty"`
+	Timeframe *AlertsFilterTimeframe `json:"timeframe,omitempty"`
+}
+
+type AlertsFilterQuery struct {
+	Kql     string        `json:"kql"`
+	Filters []interface{} `json:"filters"`
+}
+
+type AlertsFilterTimeframe struct {
+	Days     []int             `json:"days"`
+	Timezone string            `json:"timezone"`
+	Hours    AlertsFilterHours `json:"hours"`
+}
+
+type AlertsFilterHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type AlertSuppression struct {
+	GroupBy               []string             `json:"group_by,omitempty"`
+	Duration              *SuppressionDuration `json:"duration,omitempty"`
+	MissingFieldsStrategy string               `json:"missing_fields_strategy,omitempty"`
+}
+
+type SuppressionDuration struct {
+	Value int    `json:"value,omitempty"`
+	Unit  string `json:"unit,omitempty"`
+}
+
+type ResponseAction struct {
+	ActionTypeID string                 `json:"action_type_id,omitempty"`
+	Params       map[string]interface{} `json:"params,omitempty"`
+}
+
+type InvestigationFields struct {
+	FieldNames []string `json:"field_names,omitempty"`
 }
 
 type MetaItem struct {
@@ -110,54 +156,61 @@ type DetectionRuleResponse struct {
 }
 
 type DetectionRule struct {
-	Actions             []ActionItem        `json:"actions,omitempty"`
-	AnomalyThreshold    int                 `json:"anomaly_threshold,omitempty"`
-	Author              []string            `json:"author,omitempty"`
-	BuildingBlockTYpe   string              `json:"building_block_type,omitempty"`
-	Description         string              `json:"description,omitempty"`
-	Enabled             bool                `json:"enabled,omitempty"`
-	EventCategoryField  string              `json:"event_category_field,omitempty"`
-	ExceptionsList      []ExceptionListItem `json:"exceptions_list,omitempty"`
-	FalsePositives      []interface{}       `json:"false_positives,omitempty"`
-	Filters             []interface{}       `json:"filters,omitempty"`
-	From                string              `json:"from,omitempty"`
-	ID                  string              `json:"id,omitempty"`
-	Immutable           bool                `json:"immutable,omitempty"`
-	Index               []string            `json:"index,omitempty"`
-	Interval            string              `json:"interval,omitempty"`
-	Language            string              `json:"language,omitempty"`
-	License             string              `json:"license,omitempty"`
-	MachineLeanJID      []string            `json:"machine_learning_job_id,omitempty"`
-	MaxSignals          int                 `json:"max_signals,omitempty"`
-	Name                string              `json:"name,omitempty"`
-	Note                string              `json:"note,omitempty"`
-	OutputIndex         string              `json:"output_index,omitempty"`
-	Query               string              `json:"query,omitempty"`
-	References          []interface{}       `json:"references,omitempty"`
-	RelatedIntegrations []interface{}       `json:"related_integrations,omitempty"`
-	RequiredFields      []interface{}       `json:"required_fields,omitempty"`
-	RiskScore           int                 `json:"risk_score,omitempty"`
-	RiskScoreMapping    []RiskScoreMapping  `json:"risk_score_mapping,omitempty"`
-	RuleID              string              `json:"rule_id,omitempty"`
-	RuleNameOverride    string              `json:"rule_name_override,omitempty"`
-	SaveId              string              `json:"setup,omitempty"`
-	Setup               string              `json:"saved_id,omitempty"`
-	Severity            string              `json:"severity,omitempty"`
-	SeverityMapping     []SeverityMapping   `json:"severity_mapping,omitempty"`
-	Tags                []string            `json:"tags,omitempty"`
-	Threat              []ThreatItem        `json:"threat,omitempty"`
-	ThreatFilters       []interface{}       `json:"threat_filters,omitempty"`
-	ThreatIndex         []string            `json:"threat_index,omitempty"`
-	ThreatIndicatorPath string              `json:"threat_indicator_path,omitempty"`
-	ThreatQuery         string              `json:"threat_query,omitempty"`
-	ThreatMapping       []ThreatMapping     `json:"threat_mapping,omitempty"`
-	Threshold           RuleThreshold       `json:"threshold,omitempty"`
-	Throttle            string              `json:"throttle,omitempty"`
-	TiebreakerField     string              `json:"tiebreaker_field,omitempty"`
-	TimestampField      string              `json:"timestamp_field,omitempty"`
-	TimeStampOverride   string              `json:"timestamp_override,omitempty"`
-	To                  string              `json:"to,omitempty"`
-	Type                string              `json:"type,omitempty"`
-	UpdatedBy           string              `json:"updated_by,omitempty"`
-	Version             int                 `json:"version,omitempty"`
+	Actions             []ActionItem         `json:"actions,omitempty"`
+	AlertSuppression    *AlertSuppression    `json:"alert_suppression,omitempty"`
+	AnomalyThreshold    int                  `json:"anomaly_threshold,omitempty"`
+	Author              []string             `json:"author,omitempty"`
+	BuildingBlockTYpe   string               `json:"building_block_type,omitempty"`
+	DataViewID          string               `json:"data_view_id,omitempty"`
+	Description         string               `json:"description,omitempty"`
+	Enabled             *bool                `json:"enabled,omitempty"`
+	EventCategoryField  string               `json:"event_category_field,omitempty"`
+	ExceptionsList      []ExceptionListItem  `json:"exceptions_list,omitempty"`
+	FalsePositives      []interface{}        `json:"false_positives,omitempty"`
+	Filters             []interface{}        `json:"filters,omitempty"`
+	From                string               `json:"from,omitempty"`
+	ID                  string               `json:"id,omitempty"`
+	Immutable           bool                 `json:"immutable,omitempty"`
+	Index               []string             `json:"index,omitempty"`
+	Interval            string               `json:"interval,omitempty"`
+	InvestigationFields *InvestigationFields `json:"investigation_fields,omitempty"`
+	Language            string               `json:"language,omitempty"`
+	License             string               `json:"license,omitempty"`
+	MachineLeanJID      []string             `json:"machine_learning_job_id,omitempty"`
+	MaxSignals          int                  `json:"max_signals,omitempty"`
+	Name                string               `json:"name,omitempty"`
+	NewTermsFields      []string             `json:"new_terms_fields,omitempty"`
+	HistoryWindowStart  string               `json:"history_window_start,omitempty"`
+	Note                string               `json:"note,omitempty"`
+	OutputIndex         string               `json:"output_index,omitempty"`
+	Query               string               `json:"query,omitempty"`
+	References          []interface{}        `json:"references,omitempty"`
+	RelatedIntegrations []interface{}        `json:"related_integrations,omitempty"`
+	RequiredFields      []interface{}        `json:"required_fields,omitempty"`
+	ResponseActions     []ResponseAction     `json:"response_actions,omitempty"`
+	RiskScore           int                  `json:"risk_score,omitempty"`
+	RiskScoreMapping    []RiskScoreMapping   `json:"risk_score_mapping,omitempty"`
+	RuleID              string               `json:"rule_id,omitempty"`
+	RuleNameOverride    string               `json:"rule_name_override,omitempty"`
+	Setup               string               `json:"setup,omitempty"`
+	SavedID             string               `json:"saved_id,omitempty"`
+	Severity            string               `json:"severity,omitempty"`
+	SeverityMapping     []SeverityMapping    `json:"severity_mapping,omitempty"`
+	Tags                []string             `json:"tags,omitempty"`
+	Threat              []ThreatItem         `json:"threat,omitempty"`
+	ThreatFilters       []interface{}        `json:"threat_filters,omitempty"`
+	ThreatIndex         []string             `json:"threat_index,omitempty"`
+	ThreatIndicatorPath string               `json:"threat_indicator_path,omitempty"`
+	ThreatLanguage      string               `json:"threat_language,omitempty"`
+	ThreatQuery         string               `json:"threat_query,omitempty"`
+	ThreatMapping       []ThreatMapping      `json:"threat_mapping,omitempty"`
+	Threshold           RuleThreshold        `json:"threshold,omitempty"`
+	Throttle            string               `json:"throttle,omitempty"`
+	TiebreakerField     string               `json:"tiebreaker_field,omitempty"`
+	TimestampField      string               `json:"timestamp_field,omitempty"`
+	TimeStampOverride   string               `json:"timestamp_override,omitempty"`
+	To                  string               `json:"to,omitempty"`
+	Type                string               `json:"type,omitempty"`
+	UpdatedBy           string               `json:"updated_by,omitempty"`
+	Version             int                  `json:"version,omitempty"`
 }
@@ -30,29 +30,29 @@ type PrivilegesResponse struct {
 		TransportClient       bool `json:"transport_client,omitempty"`
 		CreateSnapshot        bool `json:"create_snapshot,omitempty"`
 	} `json:"cluster,omitempty"`
-	Index struct {
-		AlertsSecurityAlertsDefault struct {
-			All               bool `json:"all,omitempty"`
-			Create            bool `json:"create,omitempty"`
-			CreateDoc         bool `json:"create_doc,omitempty"`
-			CreateIndex       bool `json:"create_index,omitempty"`
-			Delete            bool `json:"delete,omitempty"`
-			DeleteIndex       bool `json:"delete_index,omitempty"`
-			Index             bool `json:"index,omitempty"`
-			Maintenance       bool `json:"maintenance,omitempty"`
-			Manage            bool `json:"manage,omitempty"`
-			ManageFollowIndex bool `json:"manage_follow_index,omitempty"`
-			ManageIlm         bool `json:"manage_ilm,omitempty"`
-			ManageLeaderIndex bool `json:"manage_leader_index,omitempty"`
-			Monitor           bool `json:"monitor,omitempty"`
-			Read              bool `json:"read,omitempty"`
-			ReadCrossCluster  bool `json:"read_cross_cluster,omitempty"`
-			ViewIndexMetadata bool `json:"view_index_metadata,omitempty"`
-			Write             bool `json:"write,omitempty"`
-		} `json:".alerts-security.alerts-default,omitempty"`
-	} `json:"index,omitempty"`
+	Index       map[string]IndexPrivileges `json:"index,omitempty"`
 	Application struct {
 	} `json:"application,omitempty"`
 	IsAuthenticated  bool `json:"is_authenticated,omitempty"`
 	HasEncryptionKey bool `json:"has_encryption_key,omitempty"`
 }
+
+type IndexPrivileges struct {
+	All               bool `json:"all,omitempty"`
+	Create            bool `json:"create,omitempty"`
+	CreateDoc         bool `json:"create_doc,omitempty"`
+	CreateIndex       bool `json:"create_index,omitempty"`
+	Delete            bool `json:"delete,omitempty"`
+	DeleteIndex       bool `json:"delete_index,omitempty"`
+	Index             bool `json:"index,omitempty"`
+	Maintenance       bool `json:"maintenance,omitempty"`
+	Manage            bool `json:"manage,omitempty"`
+	ManageFollowIndex bool `json:"manage_follow_index,omitempty"`
+	ManageIlm         bool `json:"manage_ilm,omitempty"`
+	ManageLeaderIndex bool `json:"manage_leader_index,omitempty"`
+	Monitor           bool `json:"monitor,omitempty"`
+	Read              bool `json:"read,omitempty"`
+	ReadCrossCluster  bool `json:"read_cross_cluster,omitempty"`
+	ViewIndexMetadata bool `json:"view_index_metadata,omitempty"`
+	Write             bool `json:"write,omitempty"`
+}